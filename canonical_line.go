@@ -0,0 +1,66 @@
+package httpclerk
+
+import (
+	"context"
+	"time"
+)
+
+// AddField adds a field to the one record Middleware logs for the request
+// that ctx belongs to, so handlers deep in the stack can build up a single
+// canonical log line instead of logging lines of their own:
+//
+//	httpclerk.AddField(r.Context(), "account_id", 42)
+//
+// Fields are merged in like those from a FieldExtractor, after them. It is
+// safe for concurrent use, and a no-op for a context that didn't come from
+// Middleware.
+func AddField(ctx context.Context, key string, value interface{}) {
+	state, ok := ctx.Value(stateKey).(*requestState)
+	if !ok {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.fields == nil {
+		state.fields = map[string]interface{}{}
+	}
+	state.fields[key] = value
+}
+
+// AddTiming adds d to the time spent on name during the request, e.g. in the
+// database. Timings are logged together in a "timings" field, in the
+// logger's DurationUnit. Like AddField, it is safe for concurrent use and a
+// no-op outside Middleware.
+func AddTiming(ctx context.Context, name string, d time.Duration) {
+	state, ok := ctx.Value(stateKey).(*requestState)
+	if !ok {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.timings == nil {
+		state.timings = map[string]time.Duration{}
+	}
+	state.timings[name] += d
+}
+
+func (state *requestState) mergeInto(f *fields, unit DurationUnit) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	for key, value := range state.fields {
+		f.addExtra(key, value)
+	}
+
+	if len(state.timings) > 0 {
+		timings := map[string]interface{}{}
+		for name, d := range state.timings {
+			timings[name] = unit.format(d)
+		}
+		f.addExtra("timings", timings)
+	}
+}
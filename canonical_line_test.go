@@ -0,0 +1,98 @@
+package httpclerk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCanonicalLine_fieldsAndTimings(t *testing.T) {
+	memBackend, logger := loadLogger()
+	logger.DurationUnit = DurationNanoseconds
+	res, req := createRequestAndResponse()
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddField(r.Context(), "account_id", 42)
+		AddField(r.Context(), "status", "overridden?")
+		AddTiming(r.Context(), "db", 3*time.Millisecond)
+		AddTiming(r.Context(), "db", 2*time.Millisecond)
+		AddTiming(r.Context(), "cache", time.Millisecond)
+	}))
+	handler.ServeHTTP(res, req)
+
+	if count := countRecords(memBackend); count != 1 {
+		t.Fatal("Expected exactly one log record, got", count)
+	}
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["account_id"] != 42.0 {
+		t.Error("@fields['account_id'] not set correctly, expected 42, got", fields["account_id"])
+	}
+
+	if fields["status"] != "200" {
+		t.Error("@fields['status'] overridden by AddField, expected 200, got", fields["status"])
+	}
+
+	timings := fields["timings"].(map[string]interface{})
+	if timings["db"] != float64(5*time.Millisecond) {
+		t.Error("@fields['timings']['db'] not accumulated, expected 5ms, got", timings["db"])
+	}
+
+	if timings["cache"] != float64(time.Millisecond) {
+		t.Error("@fields['timings']['cache'] not set correctly, expected 1ms, got", timings["cache"])
+	}
+}
+
+func TestCanonicalLine_concurrentHandlers(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				AddField(r.Context(), fmt.Sprintf("worker_%d", i), i)
+				AddTiming(r.Context(), "work", time.Millisecond)
+			}(i)
+		}
+		wg.Wait()
+	}))
+	handler.ServeHTTP(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	for i := 0; i < 20; i++ {
+		if fields[fmt.Sprintf("worker_%d", i)] != float64(i) {
+			t.Error("Field from worker", i, "missing")
+		}
+	}
+
+	timings := fields["timings"].(map[string]interface{})
+	if timings["work"] != 20.0 {
+		t.Error("@fields['timings']['work'] not accumulated, expected 20ms, got", timings["work"])
+	}
+}
+
+func TestCanonicalLine_outsideMiddleware(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	AddField(req.Context(), "account_id", 42)
+	AddTiming(context.Background(), "db", time.Millisecond)
+	logger.Info(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if _, ok := fields["account_id"]; ok {
+		t.Error("AddField outside Middleware should be a no-op, got", fields["account_id"])
+	}
+}
@@ -0,0 +1,105 @@
+package httpclerk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CommonLogFormatter formats HTTPLogger records in the NCSA Common Log
+// Format understood by GoAccess, AWStats, fail2ban and friends:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+//
+// or, with Combined set, the Combined Log Format, which adds the Referer and
+// User-Agent:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+type CommonLogFormatter struct {
+	Combined bool
+}
+
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// NewCommonLogFormatter constructor
+func NewCommonLogFormatter() (*CommonLogFormatter, error) {
+	return &CommonLogFormatter{}, nil
+}
+
+// NewCombinedLogFormatter constructor
+func NewCombinedLogFormatter() (*CommonLogFormatter, error) {
+	return &CommonLogFormatter{Combined: true}, nil
+}
+
+func (formatter *CommonLogFormatter) Format(customFields interface{}) (string, error) {
+	f, ok := customFields.(*fields)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("CommonLogFormatter can only format HTTPLogger fields, got %T", customFields))
+	}
+
+	host, _, err := net.SplitHostPort(f.RemoteAddr)
+	if err != nil {
+		host = f.RemoteAddr
+	}
+
+	// A structured query was taken out of the path, so put it back.
+	uri := f.Path
+	if f.Query != nil {
+		if i := strings.IndexByte(uri, '?'); i >= 0 {
+			uri = uri[:i]
+		}
+		if query := url.Values(f.Query).Encode(); query != "" {
+			uri += "?" + query
+		}
+	}
+
+	bytesOut := "-"
+	if f.BytesOut > 0 {
+		bytesOut = strconv.FormatInt(f.BytesOut, 10)
+	}
+
+	data := fmt.Sprintf(`%s - %s [%s] "%s" %s %s`,
+		orDash(host),
+		orDash(escapeCommonLog(f.User)),
+		f.started.Format(commonLogTime),
+		escapeCommonLog(f.Method+" "+uri+" "+f.Protocol),
+		orDash(f.Status),
+		bytesOut,
+	)
+
+	if formatter.Combined {
+		data += fmt.Sprintf(` "%s" "%s"`, orDash(escapeCommonLog(f.Referer)), orDash(escapeCommonLog(f.UserAgent)))
+	}
+
+	return data, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// Escapes quotes, backslashes and unprintable bytes the way Apache does, so
+// a field can't break out of its quotes or inject a fake line.
+func escapeCommonLog(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&buf, `\x%02x`, c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
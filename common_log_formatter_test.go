@@ -0,0 +1,116 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commonLogFields() *fields {
+	return &fields{
+		Method:     "GET",
+		Status:     "200",
+		Path:       "/apache_pb.gif",
+		Protocol:   "HTTP/1.0",
+		RemoteAddr: "127.0.0.1:51234",
+		User:       "frank",
+		BytesOut:   2326,
+		Referer:    "http://www.example.com/start.html",
+		UserAgent:  "Mozilla/4.08 [en] (Win98; I ;Nav)",
+		started:    time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+	}
+}
+
+func TestCommonLogFormat(t *testing.T) {
+	formatter, _ := NewCommonLogFormatter()
+
+	data, err := formatter.Format(commonLogFields())
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	expected := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+	if data != expected {
+		t.Error("Common Log Format line not formatted correctly, expected", expected, "got", data)
+	}
+}
+
+func TestCombinedLogFormat(t *testing.T) {
+	formatter, _ := NewCombinedLogFormatter()
+
+	data, _ := formatter.Format(commonLogFields())
+
+	expected := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`
+	if data != expected {
+		t.Error("Combined Log Format line not formatted correctly, expected", expected, "got", data)
+	}
+}
+
+func TestCombinedLogFormat_missingValues(t *testing.T) {
+	formatter, _ := NewCombinedLogFormatter()
+
+	data, _ := formatter.Format(&fields{Method: "GET", Path: "/", Protocol: "HTTP/1.1", started: time.Now()})
+
+	r := regexp.MustCompile(`^- - - \[[^\]]+\] "GET / HTTP/1.1" - - "-" "-"$`)
+	if !r.MatchString(data) {
+		t.Error("Missing values not logged as '-', got", data)
+	}
+}
+
+func TestCombinedLogFormat_escaping(t *testing.T) {
+	formatter, _ := NewCombinedLogFormatter()
+	f := commonLogFields()
+	f.UserAgent = "evil\" \\agent\n127.0.0.1 - - [fake]"
+
+	data, _ := formatter.Format(f)
+
+	r := regexp.MustCompile(`"evil\\" \\\\agent\\x0a127\.0\.0\.1 - - \[fake\]"$`)
+	if !r.MatchString(data) {
+		t.Error("User-Agent not escaped, got", data)
+	}
+}
+
+func TestCommonLogFormat_throughMiddleware(t *testing.T) {
+	formatter, _ := NewCombinedLogFormatter()
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req := httptest.NewRequest("POST", "http://www.foo.com/login?token=abc", nil)
+	req.SetBasicAuth("jane", "s3cr3t")
+	req.Header.Set("Referer", "http://www.foo.com/")
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("nope"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lastWrite := memBackend.Head().Record.Message()
+	r := regexp.MustCompile(`^192\.0\.2\.1 - jane \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /login\?token=REDACTED HTTP/1.1" 401 4 "http://www.foo.com/" "curl/7.30.0"$`)
+	if !r.MatchString(lastWrite) {
+		t.Error("Request not logged in Combined Log Format, got", lastWrite)
+	}
+}
+
+func TestCommonLogFormat_structuredQuery(t *testing.T) {
+	formatter, _ := NewCommonLogFormatter()
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.QueryPolicy.Structured = true
+	req := httptest.NewRequest("GET", "http://www.foo.com/p?a=1&token=z", nil)
+
+	logger.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	if lastWrite := memBackend.Head().Record.Message(); !strings.Contains(lastWrite, `"GET /p?a=1&token=REDACTED HTTP/1.1"`) {
+		t.Error("Query missing from the request line, got", lastWrite)
+	}
+}
+
+func TestCommonLogFormat_otherFields(t *testing.T) {
+	formatter, _ := NewCommonLogFormatter()
+
+	if _, err := formatter.Format(&testingFields{}); err == nil {
+		t.Error("Expected an error formatting something other than HTTPLogger fields")
+	}
+}
@@ -0,0 +1,137 @@
+package httpclerk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes used by DevFormatter.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+	colorDim    = "\x1b[2m"
+)
+
+// DevFormatter formats records for reading in a terminal during local
+// development, one aligned line per request:
+//
+//	13:55:36 GET     200    12.3ms /search?q=go tenant=acme
+//	13:55:37 POST    500   104.0ms /orders
+//
+// Status codes are colored (2xx green, 3xx cyan, 4xx yellow, 5xx red) when
+// Color is set. In Verbose mode the headers follow on indented lines.
+type DevFormatter struct {
+	Color   bool
+	Verbose bool
+}
+
+// NewDevFormatter constructor. Color is turned on if output, where the
+// records end up, is a terminal and NO_COLOR isn't set to a non-empty
+// value; nil means os.Stderr.
+func NewDevFormatter(output *os.File, verbose bool) (*DevFormatter, error) {
+	if output == nil {
+		output = os.Stderr
+	}
+	return &DevFormatter{Color: useColor(output), Verbose: verbose}, nil
+}
+
+// See https://no-color.org
+func useColor(output *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := output.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (formatter *DevFormatter) Format(customFields interface{}) (string, error) {
+	f, ok := customFields.(*fields)
+	if !ok {
+		return fmt.Sprintf("%s %+v", time.Now().Format("15:04:05"), customFields), nil
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s %-7s %s %9s %s",
+		formatter.paint(colorDim, f.started.Format("15:04:05")),
+		f.Method,
+		formatter.paint(statusColor(f.Status), fmt.Sprintf("%3s", orDashes(f.Status))),
+		devDuration(f.elapsed),
+		f.Path,
+	)
+
+	for _, key := range sortedKeys(f.Extra) {
+		fmt.Fprintf(&buf, " %s=%s", formatter.paint(colorDim, key), logfmtValue(f.Extra[key]))
+	}
+
+	if formatter.Verbose {
+		names := make([]string, 0, len(f.Headers))
+		for name := range f.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&buf, "\n    %s %s", formatter.paint(colorDim, name+":"), strings.Join(f.Headers[name], ", "))
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func (formatter *DevFormatter) paint(color, s string) string {
+	if !formatter.Color || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func statusColor(status string) string {
+	if status == "" {
+		return ""
+	}
+
+	switch status[0] {
+	case '2':
+		return colorGreen
+	case '3':
+		return colorCyan
+	case '4':
+		return colorYellow
+	case '5':
+		return colorRed
+	}
+	return ""
+}
+
+func orDashes(status string) string {
+	if status == "" {
+		return "---"
+	}
+	return status
+}
+
+// Short and readable at a glance, e.g. 850µs, 12.3ms or 1.25s
+func devDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d/time.Microsecond)
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
@@ -0,0 +1,116 @@
+package httpclerk
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func devFields() *fields {
+	return &fields{
+		Method:  "GET",
+		Status:  "404",
+		Path:    "/search?q=go",
+		Headers: map[string][]string{"X-Foo": []string{"Gaz"}, "Accept": []string{"*/*"}},
+		Extra:   map[string]interface{}{"tenant": "acme"},
+		started: time.Date(2000, 10, 10, 13, 55, 36, 0, time.UTC),
+		elapsed: 12345 * time.Microsecond,
+	}
+}
+
+func TestDevFormat(t *testing.T) {
+	formatter := &DevFormatter{}
+
+	data, err := formatter.Format(devFields())
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	expected := `13:55:36 GET     404    12.3ms /search?q=go tenant=acme`
+	if data != expected {
+		t.Error("Fields not formatted correctly, expected\n", expected, "\ngot\n", data)
+	}
+}
+
+func TestDevFormat_alignment(t *testing.T) {
+	formatter := &DevFormatter{}
+
+	f := devFields()
+	f.Method, f.Status, f.elapsed, f.Extra = "OPTIONS", "", 850*time.Microsecond, nil
+	short, _ := formatter.Format(f)
+
+	f.Method, f.Status, f.elapsed = "PUT", "200", 1250*time.Millisecond
+	long, _ := formatter.Format(f)
+
+	if short != `13:55:36 OPTIONS ---     850µs /search?q=go` {
+		t.Error("Fields not aligned correctly, got", short)
+	}
+
+	if long != `13:55:36 PUT     200     1.25s /search?q=go` {
+		t.Error("Fields not aligned correctly, got", long)
+	}
+}
+
+func TestDevFormat_color(t *testing.T) {
+	formatter := &DevFormatter{Color: true}
+	f := devFields()
+
+	for status, color := range map[string]string{"200": colorGreen, "302": colorCyan, "404": colorYellow, "503": colorRed} {
+		f.Status = status
+		data, _ := formatter.Format(f)
+		if !strings.Contains(data, color+status+colorReset) {
+			t.Errorf("Status %s not colored correctly, got %q", status, data)
+		}
+	}
+}
+
+func TestDevFormat_verbose(t *testing.T) {
+	formatter := &DevFormatter{Verbose: true}
+
+	data, _ := formatter.Format(devFields())
+
+	lines := strings.Split(data, "\n")
+	if len(lines) != 3 || lines[1] != "    Accept: */*" || lines[2] != "    X-Foo: Gaz" {
+		t.Error("Headers not listed in verbose mode, got", lines)
+	}
+
+	formatter.Verbose = false
+	if data, _ := formatter.Format(devFields()); strings.Contains(data, "X-Foo") {
+		t.Error("Headers listed outside verbose mode, got", data)
+	}
+}
+
+func TestNewDevFormatter_noColor(t *testing.T) {
+	file, _ := ioutil.TempFile("", "httpclerk")
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	formatter, _ := NewDevFormatter(file, false)
+	if formatter.Color {
+		t.Error("Color turned on for output that isn't a terminal")
+	}
+
+	// Character devices pass for terminals
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal("Error opening", os.DevNull, err)
+	}
+	defer devNull.Close()
+	if info, _ := devNull.Stat(); info.Mode()&os.ModeCharDevice == 0 {
+		t.Skip(os.DevNull, "is not a character device")
+	}
+
+	defer os.Unsetenv("NO_COLOR")
+
+	os.Setenv("NO_COLOR", "")
+	if !useColor(devNull) {
+		t.Error("Color turned off with NO_COLOR empty")
+	}
+
+	os.Setenv("NO_COLOR", "1")
+	if useColor(devNull) {
+		t.Error("Color turned on with NO_COLOR set")
+	}
+}
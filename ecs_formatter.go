@@ -0,0 +1,137 @@
+package httpclerk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the version of the Elastic Common Schema ECSFormatter
+// documents follow.
+const ECSVersion = "8.11.0"
+
+// ECSFormatter formats records as Elastic Common Schema JSON documents,
+// which modern Elasticsearch index templates map out of the box:
+//
+//	{"@timestamp":"...","http":{"request":{"method":"GET"},"response":{"status_code":200}},"url":{"path":"/foo"},...}
+//
+// Headers and custom fields have no place in ECS, so they go under a custom
+// namespace, e.g. httpclerk.headers and httpclerk.tenant.
+type ECSFormatter struct {
+	ServiceName string
+	// Custom namespace for headers and custom fields. Defaults to "httpclerk".
+	Namespace string
+}
+
+// NewECSFormatter constructor
+func NewECSFormatter(serviceName string) (*ECSFormatter, error) {
+	return &ECSFormatter{ServiceName: serviceName, Namespace: "httpclerk"}, nil
+}
+
+func (formatter *ECSFormatter) Format(customFields interface{}) (string, error) {
+	namespace := formatter.Namespace
+	if namespace == "" {
+		namespace = "httpclerk"
+	}
+
+	doc := map[string]interface{}{
+		"ecs":     map[string]interface{}{"version": ECSVersion},
+		"service": map[string]interface{}{"name": formatter.ServiceName},
+	}
+
+	f, ok := customFields.(*fields)
+	if ok {
+		formatter.addFields(doc, namespace, f)
+	} else {
+		doc["@timestamp"] = time.Now().Format(time.RFC3339Nano)
+		doc[namespace] = customFields
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	return string(data), nil
+}
+
+func (formatter *ECSFormatter) addFields(doc map[string]interface{}, namespace string, f *fields) {
+	doc["@timestamp"] = f.started.Format(time.RFC3339Nano)
+
+	event := map[string]interface{}{
+		"kind":     "event",
+		"category": []string{"web"},
+		"type":     []string{"access"},
+	}
+	if f.elapsed > 0 {
+		event["duration"] = f.elapsed.Nanoseconds()
+	}
+	doc["event"] = event
+
+	request := map[string]interface{}{
+		"method": f.Method,
+		"body":   map[string]interface{}{"bytes": f.BytesIn},
+	}
+	if f.Referer != "" {
+		request["referrer"] = f.Referer
+	}
+	response := map[string]interface{}{
+		"body": map[string]interface{}{"bytes": f.BytesOut},
+	}
+	if status, err := strconv.Atoi(f.Status); err == nil {
+		response["status_code"] = status
+	}
+	httpFields := map[string]interface{}{"request": request, "response": response}
+	if strings.HasPrefix(f.Protocol, "HTTP/") {
+		httpFields["version"] = strings.TrimPrefix(f.Protocol, "HTTP/")
+	}
+	doc["http"] = httpFields
+
+	path, query := f.Path, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	if f.Query != nil {
+		query = url.Values(f.Query).Encode()
+	}
+	urlFields := map[string]interface{}{"path": path}
+	if query != "" {
+		urlFields["query"] = query
+	}
+	if host, _, err := net.SplitHostPort(f.Host); err == nil {
+		urlFields["domain"] = host
+	} else if f.Host != "" {
+		urlFields["domain"] = f.Host
+	}
+	doc["url"] = urlFields
+
+	if f.UserAgent != "" {
+		doc["user_agent"] = map[string]interface{}{"original": f.UserAgent}
+	}
+
+	if host, port, err := net.SplitHostPort(f.RemoteAddr); err == nil {
+		source := map[string]interface{}{"address": host}
+		if net.ParseIP(host) != nil {
+			source["ip"] = host
+		}
+		if p, err := strconv.Atoi(port); err == nil {
+			source["port"] = p
+		}
+		doc["source"] = source
+	}
+
+	if f.User != "" {
+		doc["user"] = map[string]interface{}{"name": f.User}
+	}
+
+	custom := map[string]interface{}{"headers": f.Headers}
+	for key, value := range f.Extra {
+		custom[key] = value
+	}
+	doc[namespace] = custom
+}
@@ -0,0 +1,200 @@
+package httpclerk
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A subset of ecs_flat.yml from the ECS release matching ECSVersion,
+// covering the fields ECSFormatter writes.
+type ecsDefinition struct {
+	Type          string   `json:"type"`
+	Normalize     []string `json:"normalize"`
+	AllowedValues []string `json:"allowed_values"`
+}
+
+func loadECSDefinitions(t *testing.T) map[string]ecsDefinition {
+	data, err := ioutil.ReadFile("testdata/ecs_flat.json")
+	if err != nil {
+		t.Fatal("Error reading ECS definitions", err)
+	}
+
+	var definitions map[string]ecsDefinition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		t.Fatal("Error decoding ECS definitions", err)
+	}
+	return definitions
+}
+
+// Flattens nested objects to dotted keys, as ECS names fields.
+func flattenECS(prefix string, m map[string]interface{}, flat map[string]interface{}) {
+	for key, value := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenECS(key, nested, flat)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+func validateECS(t *testing.T, doc map[string]interface{}, namespace string) {
+	definitions := loadECSDefinitions(t)
+
+	flat := map[string]interface{}{}
+	flattenECS("", doc, flat)
+
+	for name, value := range flat {
+		if strings.HasPrefix(name, namespace+".") {
+			continue
+		}
+
+		definition, ok := definitions[name]
+		if !ok {
+			t.Error("Field", name, "is not defined by ECS")
+			continue
+		}
+
+		values := []interface{}{value}
+		if list, ok := value.([]interface{}); ok {
+			if len(definition.Normalize) == 0 || definition.Normalize[0] != "array" {
+				t.Error("Field", name, "is not an array in ECS, got", value)
+			}
+			values = list
+		}
+
+		for _, v := range values {
+			if !validECSValue(definition, v) {
+				t.Error("Field", name, "is not a valid", definition.Type, "got", v)
+			}
+		}
+	}
+}
+
+func validECSValue(definition ecsDefinition, value interface{}) bool {
+	switch definition.Type {
+	case "long":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "date":
+		s, ok := value.(string)
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return ok && err == nil
+	case "ip":
+		s, ok := value.(string)
+		return ok && net.ParseIP(s) != nil
+	case "keyword", "wildcard":
+		s, ok := value.(string)
+		if !ok || len(definition.AllowedValues) == 0 {
+			return ok
+		}
+		for _, allowed := range definition.AllowedValues {
+			if s == allowed {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func TestECSFormat(t *testing.T) {
+	formatter, _ := NewECSFormatter("fooService")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	req := httptest.NewRequest("POST", "http://www.foo.com:8080/items?page=2&token=abc", strings.NewReader("body"))
+	req.Header.Set("User-Agent", "curl/7.30.0")
+	req.Header.Set("Referer", "http://www.foo.com/")
+	req.SetBasicAuth("jane", "s3cr3t")
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	doc, err := decodeJSONToMap(memBackend.Head().Record.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateECS(t, doc, "httpclerk")
+
+	flat := map[string]interface{}{}
+	flattenECS("", doc, flat)
+
+	expected := map[string]interface{}{
+		"ecs.version":               ECSVersion,
+		"service.name":              "fooService",
+		"http.request.method":       "POST",
+		"http.request.body.bytes":   4.0,
+		"http.request.referrer":     "http://www.foo.com/",
+		"http.response.status_code": 201.0,
+		"http.response.body.bytes":  7.0,
+		"http.version":              "1.1",
+		"url.path":                  "/items",
+		"url.query":                 "page=2&token=REDACTED",
+		"url.domain":                "www.foo.com",
+		"user_agent.original":       "curl/7.30.0",
+		"source.ip":                 "192.0.2.1",
+		"source.port":               1234.0,
+		"user.name":                 "jane",
+		"httpclerk.tenant":          "acme",
+	}
+	for name, value := range expected {
+		if flat[name] != value {
+			t.Error("Field", name, "not set correctly, expected", value, "got", flat[name])
+		}
+	}
+
+	if _, ok := flat["event.duration"]; !ok {
+		t.Error("Field event.duration not set")
+	}
+
+	headers := doc["httpclerk"].(map[string]interface{})["headers"].(map[string]interface{})
+	if headers["Authorization"].([]interface{})[0] != "[REDACTED]" {
+		t.Error("Header Authorization not masked, got", headers["Authorization"])
+	}
+}
+
+func TestECSFormat_structuredQuery(t *testing.T) {
+	formatter, _ := NewECSFormatter("fooService")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.QueryPolicy.Structured = true
+	req, _ := http.NewRequest("GET", "http://www.foo.com/items?page=2&token=abc", nil)
+
+	logger.Info(httptest.NewRecorder(), req)
+
+	doc, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	validateECS(t, doc, "httpclerk")
+
+	urlFields := doc["url"].(map[string]interface{})
+	if urlFields["path"] != "/items" || urlFields["query"] != "page=2&token=REDACTED" {
+		t.Error("URL not split correctly, got", urlFields)
+	}
+}
+
+func TestECSFormat_otherFields(t *testing.T) {
+	formatter, _ := NewECSFormatter("fooService")
+	formatter.Namespace = "custom"
+
+	data, err := formatter.Format(&testingFields{"hi", "there", 101, []int{1, 2}})
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	doc, _ := decodeJSONToMap(data)
+	validateECS(t, doc, "custom")
+
+	if doc["custom"].(map[string]interface{})["Hot"] != "hi" {
+		t.Error("Fields not set under the custom namespace, got", doc)
+	}
+}
@@ -0,0 +1,46 @@
+package httpclerk
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrorPolicy decides what HTTPLogger logs when its Formatter returns an
+// error. Whatever the policy, OnError is called and FormatErrors goes up.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyLog logs the error itself at Error level instead of the record.
+	ErrorPolicyLog ErrorPolicy = iota
+	// ErrorPolicyFallback logs the record at its own level in TextFormatter
+	// style, which can't fail.
+	ErrorPolicyFallback
+	// ErrorPolicyDiscard logs nothing, leaving it to OnError.
+	ErrorPolicyDiscard
+)
+
+// FormatErrors returns the number of records the formatter has failed to
+// format, e.g. for a health check.
+func (log *HTTPLogger) FormatErrors() uint64 {
+	return atomic.LoadUint64(&log.formatErrors)
+}
+
+func (log *HTTPLogger) formatError(err error, record Record, dest *formattedDestination) {
+	atomic.AddUint64(&log.formatErrors, 1)
+
+	req := record.request
+	if log.OnError != nil {
+		log.OnError(err, req)
+	}
+
+	switch log.ErrorPolicy {
+	case ErrorPolicyFallback:
+		formatter, _ := NewTextFormatter(log.name)
+		data, _ := formatter.Format(record.fields)
+		dest.emit(record.Level, data)
+	case ErrorPolicyDiscard:
+	default:
+		data := fmt.Sprintf("%s: error formatting %s %s: %s", log.name, req.Method, req.URL.Path, err)
+		dest.destination.Error("%s", data)
+	}
+}
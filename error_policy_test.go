@@ -0,0 +1,100 @@
+package httpclerk
+
+import (
+	"errors"
+	golog "github.com/op/go-logging"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type failingFormatter struct{}
+
+func (f *failingFormatter) Format(customFields interface{}) (string, error) {
+	return "", errors.New("json: unsupported type: chan int")
+}
+
+func TestErrorPolicy_log(t *testing.T) {
+	memBackend, logger := loadLoggerWithFormatter(&failingFormatter{})
+	res, req := createRequestAndResponse()
+
+	logger.Info(res, req)
+
+	record := memBackend.Head().Record
+	if record.Level != golog.ERROR {
+		t.Error("Error not logged at the right level, expected ERROR, got", record.Level)
+	}
+
+	if !strings.Contains(record.Message(), "json: unsupported type: chan int") {
+		t.Error("Error not logged, got", record.Message())
+	}
+
+	if !strings.Contains(record.Message(), "PUT /1234.json") {
+		t.Error("Request not identified in error, got", record.Message())
+	}
+}
+
+func TestErrorPolicy_fallback(t *testing.T) {
+	memBackend, logger := loadLoggerWithFormatter(&failingFormatter{})
+	logger.ErrorPolicy = ErrorPolicyFallback
+	res, req := createRequestAndResponse()
+
+	logger.Warning(res, req)
+
+	record := memBackend.Head().Record
+	if record.Level != golog.WARNING {
+		t.Error("Fallback not logged at the right level, expected WARNING, got", record.Level)
+	}
+
+	if !strings.Contains(record.Message(), "foo") || !strings.Contains(record.Message(), "Method: PUT Path: /1234.json") {
+		t.Error("Fallback not formatted in TextFormatter style, got", record.Message())
+	}
+}
+
+func TestErrorPolicy_discard(t *testing.T) {
+	memBackend, logger := loadLoggerWithFormatter(&failingFormatter{})
+	logger.ErrorPolicy = ErrorPolicyDiscard
+	res, req := createRequestAndResponse()
+
+	logger.Info(res, req)
+
+	if count := countRecords(memBackend); count != 0 {
+		t.Error("Expected nothing logged, got", count, "records")
+	}
+}
+
+func TestErrorPolicy_onErrorAndCounter(t *testing.T) {
+	_, logger := loadLoggerWithFormatter(&failingFormatter{})
+	res, req := createRequestAndResponse()
+
+	var called *http.Request
+	logger.OnError = func(err error, req *http.Request) {
+		called = req
+	}
+
+	logger.Info(res, req)
+	logger.Error(res, req)
+
+	if called != req {
+		t.Error("OnError not called with the request being logged")
+	}
+
+	if logger.FormatErrors() != 2 {
+		t.Error("Format errors not counted, expected 2, got", logger.FormatErrors())
+	}
+}
+
+func TestErrorPolicy_noErrors(t *testing.T) {
+	_, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	logger.OnError = func(err error, req *http.Request) {
+		t.Error("OnError called without a formatting error:", err)
+	}
+
+	logger.Info(res, req)
+
+	if logger.FormatErrors() != 0 {
+		t.Error("Format errors counted without a formatting error, got", logger.FormatErrors())
+	}
+}
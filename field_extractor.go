@@ -0,0 +1,113 @@
+package httpclerk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldExtractor returns custom fields to log along with the built-in ones,
+// e.g. a tenant ID or feature-flag state. Register extractors on
+// HTTPLogger.FieldExtractors.
+//
+// The fields are merged into the @fields object by LogStashFormatter and
+// appended to the line by TextFormatter. Keys that clash with a built-in
+// field are ignored; a later extractor overrides an earlier one.
+type FieldExtractor func(http.ResponseWriter, *http.Request) map[string]interface{}
+
+// JSON keys of the built-in fields, which custom fields can't override.
+var builtinFieldKeys = jsonKeys(reflect.TypeOf(fields{}))
+
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key != "" && key != "-" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (log *HTTPLogger) extractFields(f *fields, res http.ResponseWriter, req *http.Request) {
+	for _, extractor := range log.FieldExtractors {
+		for key, value := range extractor(res, req) {
+			f.addExtra(key, value)
+		}
+	}
+}
+
+func (f *fields) addExtra(key string, value interface{}) {
+	if builtinFieldKeys[key] {
+		return
+	}
+	if f.Extra == nil {
+		f.Extra = map[string]interface{}{}
+	}
+	f.Extra[key] = value
+}
+
+// MarshalJSON flattens Extra into the same object as the built-in fields.
+func (f *fields) MarshalJSON() ([]byte, error) {
+	type builtin fields // Has no MarshalJSON, so doesn't recurse
+
+	data, err := json.Marshal((*builtin)(f))
+	if err != nil || len(f.Extra) == 0 {
+		return data, err
+	}
+
+	extra, err := json.Marshal(f.Extra)
+	if err != nil {
+		return nil, err
+	}
+
+	// Join {"method":...} and {"tenant":...} into {"method":...,"tenant":...}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	buf.WriteByte(',')
+	buf.Write(extra[1:])
+	return buf.Bytes(), nil
+}
+
+// A field name and value, for formatters that need fields in a stable order.
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+// Returns the fields in a stable order: built-in fields in the order they
+// are declared, under their JSON keys, followed by the custom fields sorted
+// by key. Like MarshalJSON, it leaves out empty omitempty fields.
+func (f *fields) keyValues() []keyValue {
+	var kvs []keyValue
+
+	v := reflect.ValueOf(f).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")
+		if tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "omitempty" && v.Field(i).IsZero() {
+			continue
+		}
+		kvs = append(kvs, keyValue{tag[0], v.Field(i).Interface()})
+	}
+
+	for _, key := range sortedKeys(f.Extra) {
+		kvs = append(kvs, keyValue{key, f.Extra[key]})
+	}
+
+	return kvs
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
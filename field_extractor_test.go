@@ -0,0 +1,88 @@
+package httpclerk
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func tenantExtractor(res http.ResponseWriter, req *http.Request) map[string]interface{} {
+	return map[string]interface{}{
+		"tenant":    "acme",
+		"tenant_id": 42,
+		"method":    "HIJACKED",
+	}
+}
+
+func TestFieldExtractor_logStash(t *testing.T) {
+	memBackend, logger := loadLogger()
+	logger.FieldExtractors = []FieldExtractor{
+		tenantExtractor,
+		func(res http.ResponseWriter, req *http.Request) map[string]interface{} {
+			return map[string]interface{}{"tenant": "globex", "beta": true}
+		},
+	}
+	res, req := createRequestAndResponse()
+
+	logger.Info(res, req)
+
+	m, err := decodeJSONToMap(memBackend.Head().Record.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["tenant"] != "globex" {
+		t.Error("@fields['tenant'] not overridden by the later extractor, expected globex, got", fields["tenant"])
+	}
+
+	if fields["tenant_id"] != 42.0 {
+		t.Error("@fields['tenant_id'] not set correctly, expected 42, got", fields["tenant_id"])
+	}
+
+	if fields["beta"] != true {
+		t.Error("@fields['beta'] not set correctly, expected true, got", fields["beta"])
+	}
+
+	if fields["method"] != "PUT" {
+		t.Error("@fields['method'] overridden by an extractor, expected PUT, got", fields["method"])
+	}
+}
+
+func TestFieldExtractor_text(t *testing.T) {
+	formatter, _ := NewTextFormatter("testApp")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	res, req := createRequestAndResponse()
+
+	logger.Info(res, req)
+	lastWrite := memBackend.Head().Record.Message()
+
+	if !strings.HasSuffix(lastWrite, " tenant: acme tenant_id: 42") {
+		t.Error("Custom fields not appended to the line, got", lastWrite)
+	}
+
+	if strings.Contains(lastWrite, "HIJACKED") {
+		t.Error("Built-in field overridden by an extractor, got", lastWrite)
+	}
+}
+
+func TestFieldExtractor_unmarshallableValue(t *testing.T) {
+	memBackend, logger := loadLogger()
+	logger.FieldExtractors = []FieldExtractor{
+		func(res http.ResponseWriter, req *http.Request) map[string]interface{} {
+			return map[string]interface{}{"callback": func() {}}
+		},
+	}
+	res, req := createRequestAndResponse()
+
+	logger.Info(res, req)
+
+	if logger.FormatErrors() != 1 {
+		t.Error("Format error not counted, expected 1, got", logger.FormatErrors())
+	}
+
+	if !strings.Contains(memBackend.Head().Record.Message(), "unsupported type") {
+		t.Error("Format error not logged, got", memBackend.Head().Record.Message())
+	}
+}
@@ -0,0 +1,326 @@
+package httpclerk
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Suffix added to a file's name when it's rotated, e.g. app.log.2014-07-27T07-43-56.123
+const fileRotationTime = "2006-01-02T15-04-05.000"
+
+// FileDestination writes each record as a line to a file, rotating it by
+// size and/or daily. Rotated files are renamed with the time they were
+// rotated, e.g. app.log.2014-07-27T07-43-56.123, and can be gzipped.
+//
+// Call HandleSIGHUP to reopen the file on SIGHUP, after logrotate or the
+// like has moved it. It is safe to log to from many goroutines.
+type FileDestination struct {
+	path string
+
+	// Rotate before a line would take the file past this many bytes. 0 never rotates by size.
+	MaxSize int64
+	// Rotate on the first line logged each day, local time.
+	Daily bool
+	// Rotated files kept, deleting the oldest. 0 keeps them all.
+	MaxBackups int
+	// Gzip rotated files, adding .gz to their names.
+	Compress bool
+	// Called, if set, with every error writing, rotating or reopening the file.
+	OnError func(error)
+
+	mu      sync.Mutex
+	closed  bool
+	file    *os.File
+	size    int64
+	day     time.Time
+	last    string
+	buf     []byte
+	signals chan os.Signal
+
+	// Compression and cleanup of rotated files, in the background.
+	cleanup sync.Mutex
+	wg      sync.WaitGroup
+
+	now func() time.Time
+}
+
+// NewFileDestination constructor, opening path to append to it.
+func NewFileDestination(path string) (*FileDestination, error) {
+	dest := &FileDestination{path: path, now: time.Now}
+	if err := dest.open(); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+func (dest *FileDestination) Debug(data string, args ...interface{}) {
+	dest.write(sprintf(data, args))
+}
+
+func (dest *FileDestination) Info(data string, args ...interface{}) {
+	dest.write(sprintf(data, args))
+}
+
+func (dest *FileDestination) Warning(data string, args ...interface{}) {
+	dest.write(sprintf(data, args))
+}
+
+func (dest *FileDestination) Error(data string, args ...interface{}) {
+	dest.write(sprintf(data, args))
+}
+
+func (dest *FileDestination) Critical(data string, args ...interface{}) {
+	dest.write(sprintf(data, args))
+}
+
+// Reopen closes the file and opens path again, e.g. after it was moved.
+func (dest *FileDestination) Reopen() error {
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+
+	if dest.closed {
+		return nil
+	}
+	if dest.file != nil {
+		dest.file.Close()
+		dest.file = nil
+	}
+	return dest.open()
+}
+
+// HandleSIGHUP reopens the file whenever the process gets a SIGHUP, until
+// Close. A process that calls it is no longer stopped by SIGHUP.
+func (dest *FileDestination) HandleSIGHUP() {
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+
+	if dest.closed || dest.signals != nil {
+		return
+	}
+	dest.signals = make(chan os.Signal, 1)
+	signal.Notify(dest.signals, syscall.SIGHUP)
+
+	go func(signals chan os.Signal) {
+		for range signals {
+			dest.reportError(dest.Reopen())
+		}
+	}(dest.signals)
+}
+
+// Close closes the file, waiting for rotated files to be compressed.
+func (dest *FileDestination) Close() error {
+	dest.mu.Lock()
+	dest.closed = true
+	if dest.signals != nil {
+		signal.Stop(dest.signals)
+		close(dest.signals)
+		dest.signals = nil
+	}
+
+	var err error
+	if dest.file != nil {
+		err = dest.file.Close()
+		dest.file = nil
+	}
+	dest.mu.Unlock()
+
+	dest.wg.Wait()
+	return err
+}
+
+func (dest *FileDestination) write(record string) {
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+
+	dest.buf = append(dest.buf[:0], record...)
+	if len(record) == 0 || record[len(record)-1] != '\n' {
+		dest.buf = append(dest.buf, '\n')
+	}
+
+	if dest.closed {
+		dest.reportError(errors.New(fmt.Sprintf("Log file %s is closed", dest.path)))
+		return
+	}
+
+	// Opening failed when rotating or reopening, which may have been
+	// transient, e.g. a full disk, so try again rather than give up.
+	if dest.file == nil {
+		if err := dest.open(); err != nil {
+			dest.reportError(err)
+			return
+		}
+	}
+
+	if dest.needsRotation(int64(len(dest.buf))) {
+		if err := dest.rotate(); err != nil {
+			dest.reportError(err)
+			if dest.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := dest.file.Write(dest.buf)
+	dest.size += int64(n)
+	dest.reportError(err)
+}
+
+func (dest *FileDestination) needsRotation(length int64) bool {
+	if dest.MaxSize > 0 && dest.size > 0 && dest.size+length > dest.MaxSize {
+		return true
+	}
+	return dest.Daily && !startOfDay(dest.now()).Equal(dest.day)
+}
+
+func (dest *FileDestination) open() error {
+	file, err := os.OpenFile(dest.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	dest.file = file
+	dest.size = info.Size()
+	dest.day = startOfDay(dest.now())
+	if dest.size > 0 {
+		dest.day = startOfDay(info.ModTime())
+	}
+	return nil
+}
+
+// Renames the file and opens a new one. The rotated file is compressed and
+// old ones deleted in the background.
+func (dest *FileDestination) rotate() error {
+	dest.file.Close()
+	dest.file = nil
+
+	// Names must sort in the order files were rotated, for removeOldBackups.
+	rotated := dest.path + "." + dest.now().Format(fileRotationTime)
+	for i := 1; rotated <= dest.last || fileExists(rotated) || fileExists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s.%s-%03d", dest.path, dest.now().Format(fileRotationTime), i)
+	}
+	dest.last = rotated
+
+	renameErr := os.Rename(dest.path, rotated)
+	if err := dest.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+
+	dest.wg.Add(1)
+	go func() {
+		defer dest.wg.Done()
+		dest.cleanup.Lock()
+		defer dest.cleanup.Unlock()
+
+		if dest.Compress {
+			dest.reportError(gzipFile(rotated))
+		}
+		dest.reportError(dest.removeOldBackups())
+	}()
+
+	return nil
+}
+
+func (dest *FileDestination) removeOldBackups() error {
+	if dest.MaxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(dest.path + ".*")
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, match := range matches {
+		if isRotatedFile(strings.TrimPrefix(match, dest.path+".")) {
+			backups = append(backups, match)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return strings.TrimSuffix(backups[i], ".gz") < strings.TrimSuffix(backups[j], ".gz")
+	})
+
+	for len(backups) > dest.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz.tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(out)
+	_, err = io.Copy(writer, in)
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz.tmp")
+		return err
+	}
+
+	if err := os.Rename(path+".gz.tmp", path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (dest *FileDestination) reportError(err error) {
+	if err != nil && dest.OnError != nil {
+		dest.OnError(err)
+	}
+}
+
+// Whether a suffix is a rotation time, e.g. 2014-07-27T07-43-56.123.gz,
+// rather than another file's or one being compressed.
+func isRotatedFile(suffix string) bool {
+	if len(suffix) < len(fileRotationTime) || strings.HasSuffix(suffix, ".tmp") {
+		return false
+	}
+	_, err := time.Parse(fileRotationTime, suffix[:len(fileRotationTime)])
+	return err == nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
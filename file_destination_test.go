@@ -0,0 +1,295 @@
+package httpclerk
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func loadFileDestination(t *testing.T) (string, *FileDestination) {
+	dir, err := ioutil.TempDir("", "httpclerk")
+	if err != nil {
+		t.Fatal("Error creating directory", err)
+	}
+
+	dest, err := NewFileDestination(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal("Error creating destination", err)
+	}
+	dest.OnError = func(err error) { t.Error("Unexpected error", err) }
+	return dir, dest
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Error reading file", err)
+	}
+	return string(data)
+}
+
+// Rotated files, oldest first.
+func rotatedFiles(t *testing.T, dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "app.log.*"))
+	sort.Strings(matches)
+	return matches
+}
+
+func TestFileDestination(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	dest.Info("%s", "first 100%")
+	dest.Error("second %d", 2)
+	dest.Close()
+
+	if data := readFile(t, filepath.Join(dir, "app.log")); data != "first 100%\nsecond 2\n" {
+		t.Error("Records not written as lines, got", data)
+	}
+}
+
+func TestFileDestination_appends(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	dest.Info("%s", "before restart")
+	dest.Close()
+
+	dest, _ = NewFileDestination(filepath.Join(dir, "app.log"))
+	dest.Info("%s", "after restart")
+	dest.Close()
+
+	if data := readFile(t, filepath.Join(dir, "app.log")); data != "before restart\nafter restart\n" {
+		t.Error("File not appended to, got", data)
+	}
+}
+
+func TestFileDestination_maxSize(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	dest.MaxSize = 30
+	for i := 0; i < 7; i++ {
+		dest.Info("%s", "123456789")
+	}
+	dest.Close()
+
+	files := append(rotatedFiles(t, dir), filepath.Join(dir, "app.log"))
+	if len(files) != 3 {
+		t.Fatal("Expected 2 rotated files, got", files)
+	}
+
+	total := 0
+	for _, file := range files {
+		data := readFile(t, file)
+		if len(data) > 30 {
+			t.Error("File", file, "bigger than MaxSize, got", len(data), "bytes")
+		}
+		total += strings.Count(data, "123456789\n")
+	}
+	if total != 7 {
+		t.Error("Expected 7 lines across the files, got", total)
+	}
+}
+
+func TestFileDestination_maxBackups(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "app.log.bak"), []byte("not ours"), 0644)
+
+	dest.MaxSize = 10
+	dest.MaxBackups = 2
+	for _, line := range []string{"line-001", "line-002", "line-003", "line-004", "line-005"} {
+		dest.Info("%s", line)
+	}
+	dest.Close()
+
+	var backups []string
+	for _, file := range rotatedFiles(t, dir) {
+		if !strings.HasSuffix(file, ".bak") {
+			backups = append(backups, readFile(t, file))
+		}
+	}
+	if len(backups) != 2 || backups[0] != "line-003\n" || backups[1] != "line-004\n" {
+		t.Error("Oldest backups not deleted, got", backups)
+	}
+
+	if !fileExists(filepath.Join(dir, "app.log.bak")) {
+		t.Error("File not rotated by the destination deleted")
+	}
+}
+
+func TestFileDestination_compress(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	dest.MaxSize = 10
+	dest.Compress = true
+	dest.Info("%s", "rotated 1")
+	dest.Info("%s", "current 2")
+	dest.Close()
+
+	files := rotatedFiles(t, dir)
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".gz") {
+		t.Fatal("Rotated file not gzipped, got", files)
+	}
+
+	file, _ := os.Open(files[0])
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal("Rotated file not gzipped", err)
+	}
+	if data, _ := ioutil.ReadAll(reader); string(data) != "rotated 1\n" {
+		t.Error("Rotated file not gzipped correctly, got", string(data))
+	}
+}
+
+func TestFileDestination_daily(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2014, 7, 27, 23, 59, 0, 0, time.Local)
+	dest.now = func() time.Time { return now }
+	dest.Reopen()
+	dest.Daily = true
+
+	dest.Info("%s", "sunday")
+	now = now.Add(30 * time.Second)
+	dest.Info("%s", "still sunday")
+	now = now.Add(time.Minute)
+	dest.Info("%s", "monday")
+	dest.Close()
+
+	files := rotatedFiles(t, dir)
+	if len(files) != 1 || filepath.Base(files[0]) != "app.log.2014-07-28T00-00-30.000" {
+		t.Fatal("File not rotated once, at midnight, got", files)
+	}
+	if data := readFile(t, files[0]); data != "sunday\nstill sunday\n" {
+		t.Error("Rotated file not written correctly, got", data)
+	}
+	if data := readFile(t, filepath.Join(dir, "app.log")); data != "monday\n" {
+		t.Error("New file not written correctly, got", data)
+	}
+}
+
+func TestFileDestination_reopen(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	dest.Info("%s", "before logrotate")
+	os.Rename(path, path+".1")
+	dest.Info("%s", "still to the moved file")
+	dest.Reopen()
+	dest.Info("%s", "after reopen")
+	dest.Close()
+
+	if data := readFile(t, path+".1"); data != "before logrotate\nstill to the moved file\n" {
+		t.Error("Moved file not written correctly, got", data)
+	}
+	if data := readFile(t, path); data != "after reopen\n" {
+		t.Error("File not reopened, got", data)
+	}
+}
+
+func TestFileDestination_retriesOpen(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	var errs []error
+	dest.OnError = func(err error) { errs = append(errs, err) }
+	os.Remove(path)
+	os.Mkdir(path, 0755) // Can't be opened as a file
+	if err := dest.Reopen(); err == nil {
+		t.Fatal("Expected an error reopening a directory")
+	}
+	dest.Info("%s", "dropped")
+	os.Remove(path)
+	dest.Info("%s", "after recovering")
+	dest.Close()
+
+	if len(errs) != 1 {
+		t.Error("Failed write not reported once, got", errs)
+	}
+	if data := readFile(t, path); data != "after recovering\n" {
+		t.Error("File not opened again on the next write, got", data)
+	}
+}
+
+func TestFileDestination_closed(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	dest.OnError = func(err error) { errs = append(errs, err) }
+	dest.Close()
+	dest.Info("%s", "dropped")
+	dest.Reopen()
+
+	if len(errs) != 1 {
+		t.Error("Write after Close not reported, got", errs)
+	}
+	if data := readFile(t, filepath.Join(dir, "app.log")); data != "" {
+		t.Error("Record written after Close, got", data)
+	}
+}
+
+func TestFileDestination_concurrentRequests(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+
+	dest.MaxSize = 4096
+	dest.Compress = true
+	formatter, _ := NewLogStashFormatter("fooApp", []string{})
+	logger, _ := NewHTTPLogger("foo", dest, formatter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				res, req := createRequestAndResponse()
+				logger.Info(res, req)
+			}
+		}()
+	}
+	wg.Wait()
+	dest.Close()
+
+	var lines []string
+	for _, file := range append(rotatedFiles(t, dir), filepath.Join(dir, "app.log")) {
+		var data []byte
+		if strings.HasSuffix(file, ".gz") {
+			f, _ := os.Open(file)
+			reader, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatal("Rotated file not gzipped", err)
+			}
+			data, _ = ioutil.ReadAll(reader)
+			f.Close()
+		} else {
+			data = []byte(readFile(t, file))
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")...)
+	}
+
+	if len(lines) != 500 {
+		t.Fatal("Expected 500 lines, got", len(lines))
+	}
+	for _, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Error("Line not written whole, got", line)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package httpclerk
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileDestination_sighup(t *testing.T) {
+	dir, dest := loadFileDestination(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	dest.HandleSIGHUP()
+	dest.Info("%s", "before logrotate")
+	os.Rename(path, path+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+	for deadline := time.Now().Add(5 * time.Second); !fileExists(path); {
+		if time.Now().After(deadline) {
+			t.Fatal("File not reopened on SIGHUP")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	dest.Info("%s", "after SIGHUP")
+	dest.Close()
+
+	if data := readFile(t, path); data != "after SIGHUP\n" {
+		t.Error("File not reopened on SIGHUP, got", data)
+	}
+}
@@ -0,0 +1,330 @@
+package httpclerk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// GELFCompression is how GELFDestination compresses UDP messages.
+type GELFCompression int
+
+const (
+	GELFUncompressed GELFCompression = iota
+	GELFGzip
+	GELFZlib
+)
+
+const (
+	// Default size of a UDP chunk, which fits in a typical MTU.
+	DefaultGELFChunkSize = 1420
+	// Default time a TCP connect or write may take before the message is dropped.
+	DefaultGELFWriteTimeout = 5 * time.Second
+	// Default number of messages GELFDestination holds for TCP before dropping them.
+	DefaultGELFQueueSize = 10000
+	// Default time GELFDestination.Close waits for queued TCP messages to be sent.
+	DefaultGELFCloseTimeout = 5 * time.Second
+	// Magic bytes, message ID, sequence number and count before each chunk.
+	gelfChunkHeaderSize = 12
+	// Graylog drops messages split into more chunks than this.
+	maxGELFChunks = 128
+)
+
+// GELFDestination sends GELF messages, such as those from a GELFFormatter,
+// to Graylog over UDP or TCP.
+//
+// Over UDP, messages bigger than ChunkSize are split into GELF chunks, and
+// may be compressed. Over TCP, messages are framed by a null byte and can't
+// be compressed. They are queued and sent from a separate goroutine, so a
+// stalled Graylog never holds up logging; when the queue is full, messages
+// are dropped. Call Close to send what's queued before exiting.
+type GELFDestination struct {
+	// "udp" or "tcp"
+	network string
+	address string
+
+	// UDP only. Defaults to GELFUncompressed.
+	Compression GELFCompression
+	// UDP only. Defaults to DefaultGELFChunkSize, and must leave room for
+	// a chunk's 12 byte header.
+	ChunkSize int
+	// TCP only. How long connecting or writing a message may take.
+	// Defaults to DefaultGELFWriteTimeout.
+	WriteTimeout time.Duration
+	// TCP only. How long Close waits for queued messages. Defaults to DefaultGELFCloseTimeout.
+	CloseTimeout time.Duration
+	// Called, if set, with every error sending a message, which is dropped.
+	// Over TCP, it is called from the goroutine sending the queue.
+	OnError func(error)
+
+	mu     sync.Mutex
+	closed bool
+	conn   net.Conn
+
+	// TCP only.
+	queue chan []byte
+	start sync.Once
+	done  chan struct{}
+	abort chan struct{}
+}
+
+// NewGELFDestination constructor. network is "udp" or "tcp".
+func NewGELFDestination(network, address string) (*GELFDestination, error) {
+	if network != "udp" && network != "tcp" {
+		return nil, errors.New(fmt.Sprintf("GELF can't be sent over %q, only udp or tcp", network))
+	}
+
+	conn, err := net.DialTimeout(network, address, DefaultGELFWriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	dest := &GELFDestination{
+		network:      network,
+		address:      address,
+		ChunkSize:    DefaultGELFChunkSize,
+		WriteTimeout: DefaultGELFWriteTimeout,
+		CloseTimeout: DefaultGELFCloseTimeout,
+		conn:         conn,
+	}
+	if network == "tcp" {
+		dest.queue = make(chan []byte, DefaultGELFQueueSize)
+		dest.done = make(chan struct{})
+		dest.abort = make(chan struct{})
+	}
+	return dest, nil
+}
+
+func (dest *GELFDestination) Debug(data string, args ...interface{}) {
+	dest.send(sprintf(data, args))
+}
+
+func (dest *GELFDestination) Info(data string, args ...interface{}) {
+	dest.send(sprintf(data, args))
+}
+
+func (dest *GELFDestination) Warning(data string, args ...interface{}) {
+	dest.send(sprintf(data, args))
+}
+
+func (dest *GELFDestination) Error(data string, args ...interface{}) {
+	dest.send(sprintf(data, args))
+}
+
+func (dest *GELFDestination) Critical(data string, args ...interface{}) {
+	dest.send(sprintf(data, args))
+}
+
+// Close closes the connection to Graylog. Over TCP, it first waits up to
+// CloseTimeout for the queued messages to be sent, dropping the rest and
+// returning an error when that runs out. Messages logged after Close are
+// dropped.
+func (dest *GELFDestination) Close() error {
+	dest.mu.Lock()
+	if dest.closed {
+		dest.mu.Unlock()
+		return nil
+	}
+	dest.closed = true
+
+	if dest.network != "tcp" {
+		defer dest.mu.Unlock()
+		err := dest.conn.Close()
+		dest.conn = nil
+		return err
+	}
+
+	close(dest.queue)
+	dest.mu.Unlock()
+
+	dest.start.Do(func() { go dest.run() })
+
+	timeout := dest.CloseTimeout
+	if timeout <= 0 {
+		timeout = DefaultGELFCloseTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-dest.done:
+	case <-timer.C:
+		// The message being sent finishes within its write timeouts.
+		close(dest.abort)
+		<-dest.done
+		err = errors.New(fmt.Sprintf("GELF destination not drained after %s, queued messages dropped", timeout))
+	}
+
+	if dest.conn != nil {
+		if closeErr := dest.conn.Close(); err == nil {
+			err = closeErr
+		}
+		dest.conn = nil
+	}
+	return err
+}
+
+func (dest *GELFDestination) send(message string) {
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+
+	var err error
+	if dest.closed {
+		err = errors.New("GELF destination is closed")
+	} else if dest.network == "tcp" {
+		err = dest.enqueue([]byte(message))
+	} else {
+		err = dest.sendUDP([]byte(message))
+	}
+
+	dest.reportError(err)
+}
+
+func (dest *GELFDestination) enqueue(message []byte) error {
+	dest.start.Do(func() { go dest.run() })
+
+	select {
+	case dest.queue <- message:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("GELF queue is full at %d messages, message dropped", cap(dest.queue)))
+	}
+}
+
+// Sends the TCP queue until it is closed. Only this goroutine uses the
+// connection until Close has waited for it.
+func (dest *GELFDestination) run() {
+	defer close(dest.done)
+
+	for message := range dest.queue {
+		select {
+		case <-dest.abort:
+			continue
+		default:
+		}
+		dest.reportError(dest.sendTCP(message))
+	}
+}
+
+// Reconnects once if the connection has gone away, e.g. Graylog restarted.
+func (dest *GELFDestination) sendTCP(message []byte) error {
+	framed := append(message, 0)
+
+	timeout := dest.WriteTimeout
+	if timeout <= 0 {
+		timeout = DefaultGELFWriteTimeout
+	}
+
+	if dest.conn != nil {
+		err := dest.writeTCP(framed, timeout)
+		if err == nil {
+			return nil
+		}
+		dest.conn.Close()
+		dest.conn = nil
+	}
+
+	conn, err := net.DialTimeout(dest.network, dest.address, timeout)
+	if err != nil {
+		return err
+	}
+	dest.conn = conn
+
+	return dest.writeTCP(framed, timeout)
+}
+
+func (dest *GELFDestination) writeTCP(framed []byte, timeout time.Duration) error {
+	if err := dest.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	_, err := dest.conn.Write(framed)
+	return err
+}
+
+func (dest *GELFDestination) reportError(err error) {
+	if err != nil && dest.OnError != nil {
+		dest.OnError(err)
+	}
+}
+
+func (dest *GELFDestination) sendUDP(message []byte) error {
+	message, err := dest.compress(message)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := dest.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultGELFChunkSize
+	}
+	if chunkSize <= gelfChunkHeaderSize {
+		return errors.New(fmt.Sprintf("GELF chunk size %d leaves no room after the %d byte chunk header", chunkSize, gelfChunkHeaderSize))
+	}
+
+	if len(message) <= chunkSize {
+		_, err = dest.conn.Write(message)
+		return err
+	}
+
+	// Each chunk starts with the magic bytes, a message ID shared by all
+	// the chunks, the chunk's sequence number and the number of chunks.
+	dataSize := chunkSize - gelfChunkHeaderSize
+	count := (len(message) + dataSize - 1) / dataSize
+	if count > maxGELFChunks {
+		return errors.New(fmt.Sprintf("GELF message of %d bytes needs %d chunks, more than %d", len(message), count, maxGELFChunks))
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(message) {
+			end = len(message)
+		}
+
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, message[i*dataSize:end]...)
+
+		if _, err := dest.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dest *GELFDestination) compress(message []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+
+	switch dest.Compression {
+	case GELFGzip:
+		writer = gzip.NewWriter(&buf)
+	case GELFZlib:
+		writer = zlib.NewWriter(&buf)
+	default:
+		return message, nil
+	}
+
+	if _, err := writer.Write(message); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,285 @@
+package httpclerk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error listening on UDP", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) []byte {
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal("Error reading packet", err)
+	}
+	return buf[:n]
+}
+
+func TestGELFDestination_udp(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, err := NewGELFDestination("udp", listener.LocalAddr().String())
+	if err != nil {
+		t.Fatal("Error creating destination", err)
+	}
+	defer dest.Close()
+
+	dest.Info("%s", `{"version":"1.1","short_message":"100%"}`)
+
+	if packet := string(readPacket(t, listener)); packet != `{"version":"1.1","short_message":"100%"}` {
+		t.Error("Message not sent as is, got", packet)
+	}
+}
+
+func TestGELFDestination_udpCompression(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("udp", listener.LocalAddr().String())
+	defer dest.Close()
+
+	dest.Compression = GELFGzip
+	dest.Info("%s", "gzipped")
+	gzipReader, err := gzip.NewReader(bytes.NewReader(readPacket(t, listener)))
+	if err != nil {
+		t.Fatal("Message not gzipped", err)
+	}
+	if data, _ := ioutil.ReadAll(gzipReader); string(data) != "gzipped" {
+		t.Error("Message not gzipped correctly, got", string(data))
+	}
+
+	dest.Compression = GELFZlib
+	dest.Info("%s", "zlibbed")
+	zlibReader, err := zlib.NewReader(bytes.NewReader(readPacket(t, listener)))
+	if err != nil {
+		t.Fatal("Message not zlib compressed", err)
+	}
+	if data, _ := ioutil.ReadAll(zlibReader); string(data) != "zlibbed" {
+		t.Error("Message not zlib compressed correctly, got", string(data))
+	}
+}
+
+func TestGELFDestination_udpChunking(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("udp", listener.LocalAddr().String())
+	defer dest.Close()
+	dest.ChunkSize = 112 // 100 bytes of data per chunk
+
+	message := strings.Repeat("0123456789", 25)
+	dest.Info("%s", message)
+
+	var id []byte
+	parts := make([][]byte, 3)
+	for i := 0; i < 3; i++ {
+		chunk := readPacket(t, listener)
+		if len(chunk) > 112 || chunk[0] != 0x1e || chunk[1] != 0x0f {
+			t.Fatal("Chunk not formatted correctly, got", chunk)
+		}
+		if id == nil {
+			id = chunk[2:10]
+		} else if !bytes.Equal(id, chunk[2:10]) {
+			t.Error("Chunks don't share a message ID")
+		}
+		if chunk[11] != 3 {
+			t.Error("Chunk count not set correctly, expected 3, got", chunk[11])
+		}
+		parts[chunk[10]] = chunk[12:]
+	}
+
+	if reassembled := string(bytes.Join(parts, nil)); reassembled != message {
+		t.Error("Chunks don't reassemble to the message, got", reassembled)
+	}
+}
+
+func TestGELFDestination_udpTooManyChunks(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("udp", listener.LocalAddr().String())
+	defer dest.Close()
+	dest.ChunkSize = 13 // 1 byte of data per chunk
+
+	var sendErr error
+	dest.OnError = func(err error) { sendErr = err }
+	dest.Info("%s", strings.Repeat("x", 129))
+
+	if sendErr == nil {
+		t.Error("Expected an error sending a message needing more than 128 chunks")
+	}
+}
+
+func TestGELFDestination_udpChunkTooSmall(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("udp", listener.LocalAddr().String())
+	defer dest.Close()
+	dest.ChunkSize = 10
+
+	var sendErr error
+	dest.OnError = func(err error) { sendErr = err }
+	dest.Info("%s", strings.Repeat("x", 20))
+
+	if sendErr == nil {
+		t.Error("Expected an error chunking with no room for data")
+	}
+}
+
+func TestGELFDestination_tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error listening on TCP", err)
+	}
+	defer listener.Close()
+
+	dest, err := NewGELFDestination("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal("Error creating destination", err)
+	}
+	defer dest.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal("Error accepting connection", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	dest.Compression = GELFGzip // Ignored over TCP
+	dest.Info("%s", `{"short_message":"one"}`)
+	dest.Error("%s", `{"short_message":"two"}`)
+
+	reader := bufio.NewReader(conn)
+	for _, expected := range []string{`{"short_message":"one"}`, `{"short_message":"two"}`} {
+		message, err := reader.ReadString(0)
+		if err != nil {
+			t.Fatal("Error reading message", err)
+		}
+		if message != expected+"\x00" {
+			t.Error("Message not framed by a null byte, got", message)
+		}
+	}
+}
+
+func TestGELFDestination_tcpStalled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error listening on TCP", err)
+	}
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("tcp", listener.Addr().String())
+
+	conn, _ := listener.Accept() // and never read from
+	defer conn.Close()
+
+	errs := make(chan error, 100)
+	dest.WriteTimeout = 500 * time.Millisecond
+	dest.CloseTimeout = 50 * time.Millisecond
+	dest.OnError = func(err error) { errs <- err }
+
+	message := strings.Repeat("x", 4<<20)
+	started := time.Now()
+	for i := 0; i < 6; i++ {
+		dest.Info("%s", message)
+	}
+	if elapsed := time.Since(started); elapsed > dest.WriteTimeout {
+		t.Error("Logging held up by a stalled peer for", elapsed)
+	}
+
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write to a stalled peer not timed out")
+	}
+
+	closed := make(chan error)
+	go func() { closed <- dest.Close() }()
+
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Error("Expected an error closing with messages still queued")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a stalled peer")
+	}
+}
+
+func TestGELFDestination_closed(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error listening on TCP", err)
+	}
+	defer listener.Close()
+
+	tcp, _ := NewGELFDestination("tcp", listener.Addr().String())
+	conn, _ := listener.Accept()
+	conn.Close()
+
+	udpListener := listenUDP(t)
+	defer udpListener.Close()
+	udp, _ := NewGELFDestination("udp", udpListener.LocalAddr().String())
+
+	for _, dest := range []*GELFDestination{tcp, udp} {
+		var errs []error
+		dest.OnError = func(err error) { errs = append(errs, err) }
+		dest.Close()
+		dest.Info("%s", `{"short_message":"dropped"}`)
+
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "closed") {
+			t.Error("Message logged after Close not dropped over", dest.network, "got", errs)
+		}
+	}
+
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(100 * time.Millisecond))
+	if conn, err := listener.Accept(); err == nil {
+		conn.Close()
+		t.Error("TCP connection reopened after Close")
+	}
+}
+
+func TestGELFDestination_withHTTPLogger(t *testing.T) {
+	listener := listenUDP(t)
+	defer listener.Close()
+
+	dest, _ := NewGELFDestination("udp", listener.LocalAddr().String())
+	defer dest.Close()
+	formatter, _ := NewGELFFormatter("web-1")
+	logger, _ := NewHTTPLogger("foo", dest, formatter)
+	res, req := createRequestAndResponse()
+
+	logger.Warning(res, req)
+
+	m, err := decodeJSONToMap(string(readPacket(t, listener)))
+	if err != nil {
+		t.Fatal("Message is not valid JSON", err)
+	}
+	if m["short_message"] != "PUT /1234.json" || m["level"] != 4.0 {
+		t.Error("Message not sent correctly, got", m)
+	}
+}
+
+func TestGELFDestination_badNetwork(t *testing.T) {
+	if _, err := NewGELFDestination("unix", "/tmp/gelf.sock"); err == nil {
+		t.Error("Expected an error for a network other than udp or tcp")
+	}
+}
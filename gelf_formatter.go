@@ -0,0 +1,103 @@
+package httpclerk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GELFFormatter formats records as GELF 1.1 messages for Graylog:
+//
+//	{"version":"1.1","host":"web-1","short_message":"GET /foo 200","timestamp":1406447036.123,"level":6,"_method":"GET",...}
+//
+// Every field becomes an additional field, prefixed with an underscore.
+// Headers and other nested values are flattened, e.g. _header_user-agent,
+// as GELF only allows strings and numbers. Send the messages to Graylog with
+// a GELFDestination.
+type GELFFormatter struct {
+	// Defaults to the hostname.
+	Host string
+}
+
+// NewGELFFormatter constructor. host may be empty to use the hostname.
+func NewGELFFormatter(host string) (*GELFFormatter, error) {
+	return &GELFFormatter{Host: host}, nil
+}
+
+// Additional field names GELF accepts.
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+func (formatter *GELFFormatter) Format(customFields interface{}) (string, error) {
+	host := formatter.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	message := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": logStashMessage(customFields),
+		"timestamp":     gelfTimestamp(time.Now()),
+		"level":         syslogSeverities[LevelInfo], // GELF levels are syslog severities
+	}
+
+	f, ok := customFields.(*fields)
+	if ok {
+		message["timestamp"] = gelfTimestamp(f.started)
+		message["level"] = syslogSeverities[f.level]
+		for _, kv := range f.keyValues() {
+			key := kv.key
+			if key == "headers" {
+				key = "header"
+			}
+			addGELFField(message, "_"+key, kv.value)
+		}
+	} else {
+		m, err := toJSONMap(customFields)
+		if err != nil {
+			return "", err
+		}
+		for key, value := range m {
+			addGELFField(message, "_"+key, value)
+		}
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	return string(data), nil
+}
+
+func addGELFField(message map[string]interface{}, key string, value interface{}) {
+	key = gelfFieldName.ReplaceAllString(key, "_")
+	if key == "_id" { // Reserved by GELF
+		key = "_id_"
+	}
+
+	switch v := value.(type) {
+	case map[string][]string:
+		for name, values := range v {
+			addGELFField(message, key+"_"+strings.ToLower(name), strings.Join(values, ", "))
+		}
+	case map[string]interface{}:
+		for name, nested := range v {
+			addGELFField(message, key+"_"+name, nested)
+		}
+	case string, float64, float32, int, int64, int32, uint, uint64, uint32:
+		message[key] = v
+	case nil:
+	default:
+		message[key] = fmt.Sprint(v)
+	}
+}
+
+// Seconds since the epoch, with milliseconds.
+func gelfTimestamp(t time.Time) float64 {
+	return float64(t.UnixNano()/int64(time.Millisecond)) / 1000
+}
@@ -0,0 +1,87 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGELFFormat(t *testing.T) {
+	formatter, _ := NewGELFFormatter("web-1")
+
+	fields := &fields{
+		Method:   "GET",
+		Status:   "503",
+		Path:     "/foo",
+		Headers:  map[string][]string{"X-Foo": []string{"Gaz", "Baz"}},
+		BytesOut: 12,
+		Extra: map[string]interface{}{
+			"id":      7,
+			"beta":    true,
+			"timings": map[string]interface{}{"db": 1.5},
+		},
+		started: time.Unix(1406447036, 123000000),
+		level:   LevelError,
+	}
+
+	data, err := formatter.Format(fields)
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	m, _ := decodeJSONToMap(data)
+
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web-1",
+		"short_message": "GET /foo 503",
+		"timestamp":     1406447036.123,
+		"level":         3.0,
+		"_method":       "GET",
+		"_status":       "503",
+		"_bytes_out":    12.0,
+		"_header_x-foo": "Gaz, Baz",
+		"_id_":          7.0,
+		"_beta":         "true",
+		"_timings_db":   1.5,
+	}
+	for key, value := range expected {
+		if m[key] != value {
+			t.Error(key, "not set correctly, expected", value, "got", m[key])
+		}
+	}
+
+	for key, value := range m {
+		switch value.(type) {
+		case string, float64:
+		default:
+			t.Error(key, "is neither a string nor a number, got", value)
+		}
+	}
+}
+
+func TestGELFFormat_levels(t *testing.T) {
+	formatter, _ := NewGELFFormatter("")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req, _ := http.NewRequest("GET", "http://www.foo.com/", nil)
+
+	logger.Debug(httptest.NewRecorder(), req)
+	logger.Info(httptest.NewRecorder(), req)
+	logger.Warning(httptest.NewRecorder(), req)
+	logger.Error(httptest.NewRecorder(), req)
+	logger.Critical(httptest.NewRecorder(), req)
+
+	expected := []float64{7, 6, 4, 3, 2}
+	node := memBackend.Head()
+	for _, level := range expected {
+		m, _ := decodeJSONToMap(node.Record.Message())
+		if m["level"] != level {
+			t.Error("level not set correctly, expected", level, "got", m["level"])
+		}
+		if m["host"] == "" {
+			t.Error("host not defaulted to the hostname")
+		}
+		node = node.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package httpclerk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// DefaultDeniedHeaders are masked by the HeaderPolicy NewHTTPLogger sets up.
+var DefaultDeniedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+	"X-Session-Id",
+}
+
+// HeaderPolicy decides which request headers are logged, and hides the
+// values of sensitive ones. Header names are matched case-insensitively.
+type HeaderPolicy struct {
+	// Headers whose values are replaced by Mask, or hashed if HashKey is set.
+	Deny []string
+	// When not empty, only these headers are logged. Deny still applies.
+	Allow []string
+	// Replaces the values of denied headers. Defaults to "[REDACTED]".
+	Mask string
+	// When set, the values of denied headers are replaced by their
+	// HMAC-SHA256 under this key instead of Mask, so requests carrying the
+	// same token can be correlated without the token being revealed.
+	HashKey []byte
+}
+
+// NewHeaderPolicy constructor, denying DefaultDeniedHeaders.
+func NewHeaderPolicy() (*HeaderPolicy, error) {
+	deny := make([]string, len(DefaultDeniedHeaders))
+	copy(deny, DefaultDeniedHeaders)
+	return &HeaderPolicy{Deny: deny}, nil
+}
+
+// Returns a filtered copy of headers, leaving the request's own untouched.
+func (policy *HeaderPolicy) apply(headers http.Header) map[string][]string {
+	filtered := make(map[string][]string, len(headers))
+
+	for name, values := range headers {
+		if len(policy.Allow) > 0 && !containsFold(policy.Allow, name) {
+			continue
+		}
+
+		if !containsFold(policy.Deny, name) {
+			filtered[name] = values
+			continue
+		}
+
+		hidden := make([]string, len(values))
+		for i, value := range values {
+			hidden[i] = policy.hide(value)
+		}
+		filtered[name] = hidden
+	}
+
+	return filtered
+}
+
+func (policy *HeaderPolicy) hide(value string) string {
+	if len(policy.HashKey) > 0 {
+		mac := hmac.New(sha256.New, policy.HashKey)
+		mac.Write([]byte(value))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	if policy.Mask == "" {
+		return "[REDACTED]"
+	}
+	return policy.Mask
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package httpclerk
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loggedHeaders(t *testing.T, configure func(*HTTPLogger)) map[string]interface{} {
+	memBackend, logger := loadLogger()
+	configure(logger)
+	res, req := createRequestAndResponse()
+	req.Header.Add("Authorization", "Bearer s3cr3t")
+	req.Header.Add("Cookie", "session=abc")
+	req.Header.Add("X-Request-Id", "req-1")
+
+	logger.Info(res, req)
+
+	if req.Header.Get("Authorization") != "Bearer s3cr3t" {
+		t.Error("Request headers modified by the header policy")
+	}
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	return m["@fields"].(map[string]interface{})["headers"].(map[string]interface{})
+}
+
+func TestHeaderPolicy_defaultDenylist(t *testing.T) {
+	headers := loggedHeaders(t, func(logger *HTTPLogger) {})
+
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if !reflect.DeepEqual(headers[name], []interface{}{"[REDACTED]"}) {
+			t.Error("Header", name, "not masked, got", headers[name])
+		}
+	}
+
+	if !reflect.DeepEqual(headers["X-Request-Id"], []interface{}{"req-1"}) {
+		t.Error("Header X-Request-Id not logged as is, got", headers["X-Request-Id"])
+	}
+}
+
+func TestHeaderPolicy_customDenylistAndMask(t *testing.T) {
+	headers := loggedHeaders(t, func(logger *HTTPLogger) {
+		logger.HeaderPolicy.Deny = []string{"x-request-id"}
+		logger.HeaderPolicy.Mask = "***"
+	})
+
+	if !reflect.DeepEqual(headers["X-Request-Id"], []interface{}{"***"}) {
+		t.Error("Header X-Request-Id not masked, got", headers["X-Request-Id"])
+	}
+
+	if !reflect.DeepEqual(headers["Authorization"], []interface{}{"Bearer s3cr3t"}) {
+		t.Error("Header Authorization masked without being denied, got", headers["Authorization"])
+	}
+}
+
+func TestHeaderPolicy_allowlist(t *testing.T) {
+	headers := loggedHeaders(t, func(logger *HTTPLogger) {
+		logger.HeaderPolicy.Allow = []string{"X-Request-Id", "Authorization"}
+	})
+
+	if len(headers) != 2 {
+		t.Error("Headers not allowlisted, expected 2, got", headers)
+	}
+
+	if !reflect.DeepEqual(headers["Authorization"], []interface{}{"[REDACTED]"}) {
+		t.Error("Allowlisted header Authorization not masked, got", headers["Authorization"])
+	}
+}
+
+func TestHeaderPolicy_keyedHash(t *testing.T) {
+	hash := func(key string) string {
+		headers := loggedHeaders(t, func(logger *HTTPLogger) {
+			logger.HeaderPolicy.HashKey = []byte(key)
+		})
+		return headers["Authorization"].([]interface{})[0].(string)
+	}
+
+	first, second, otherKey := hash("k1"), hash("k1"), hash("k2")
+
+	if !strings.HasPrefix(first, "hmac-sha256:") || strings.Contains(first, "s3cr3t") {
+		t.Error("Header Authorization not hashed, got", first)
+	}
+
+	if first != second {
+		t.Error("Same value hashed differently under the same key:", first, second)
+	}
+
+	if first == otherKey {
+		t.Error("Same value hashed the same under different keys:", first)
+	}
+}
+
+func TestHeaderPolicy_disabled(t *testing.T) {
+	headers := loggedHeaders(t, func(logger *HTTPLogger) {
+		logger.HeaderPolicy = nil
+	})
+
+	if !reflect.DeepEqual(headers["Authorization"], []interface{}{"Bearer s3cr3t"}) {
+		t.Error("Header Authorization not logged as is, got", headers["Authorization"])
+	}
+}
+
+func loggedCombinedLine(configure func(*HTTPLogger)) string {
+	formatter, _ := NewCombinedLogFormatter()
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	configure(logger)
+	req := httptest.NewRequest("GET", "http://www.foo.com/", nil)
+	req.Header.Set("Referer", "http://www.foo.com/start")
+	req.Header.Set("User-Agent", "secret-agent")
+	req.Header.Set("X-Request-Id", "req-1")
+
+	logger.Info(httptest.NewRecorder(), req)
+
+	return memBackend.Head().Record.Message()
+}
+
+func TestHeaderPolicy_refererAndUserAgent(t *testing.T) {
+	line := loggedCombinedLine(func(logger *HTTPLogger) {})
+	if !strings.HasSuffix(line, `"http://www.foo.com/start" "secret-agent"`) {
+		t.Error("Referer and User-Agent not logged, got", line)
+	}
+
+	line = loggedCombinedLine(func(logger *HTTPLogger) {
+		logger.HeaderPolicy.Deny = append(logger.HeaderPolicy.Deny, "Referer", "user-agent")
+	})
+	if !strings.HasSuffix(line, `"[REDACTED]" "[REDACTED]"`) {
+		t.Error("Denied Referer and User-Agent not masked, got", line)
+	}
+
+	line = loggedCombinedLine(func(logger *HTTPLogger) {
+		logger.HeaderPolicy.Allow = []string{"X-Request-Id"}
+	})
+	if !strings.HasSuffix(line, `"-" "-"`) {
+		t.Error("Referer and User-Agent logged without being allowed, got", line)
+	}
+}
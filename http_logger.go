@@ -1,11 +1,18 @@
 package httpclerk
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // Implements similar to http://godoc.org/github.com/op/go-logging#Logger
+//
+// HTTPLogger always calls these methods with a "%s" format and the formatted
+// record as its only argument, so a '%' in a logged path or header is never
+// mistaken for a verb.
 type LogDestination interface {
 	Debug(data string, args ...interface{})
 	Info(data string, args ...interface{})
@@ -14,91 +21,303 @@ type LogDestination interface {
 	Critical(data string, args ...interface{})
 }
 
+// Expands a LogDestination call's format and arguments. Without arguments
+// the format is taken as is, so destinations don't mangle a stray '%'.
+func sprintf(format string, args []interface{}) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
 type Formatter interface {
 	Format(interface{}) (string, error)
 }
 
 type HTTPLogger struct {
+	// Accessed atomically, kept first for 64-bit alignment on 32-bit platforms.
+	formatErrors uint64
+
 	name        string
-	formatter   Formatter
-	destination LogDestination
+	destination StructuredDestination
+
+	// Unit the duration field is logged in. Defaults to DurationMilliseconds.
+	DurationUnit DurationUnit
+
+	// What gets logged when the formatter fails. Defaults to ErrorPolicyLog.
+	ErrorPolicy ErrorPolicy
+
+	// Called, if set, with every formatter error and the request being logged.
+	OnError func(error, *http.Request)
+
+	// Add custom fields to every record, in order. See FieldExtractor.
+	FieldExtractors []FieldExtractor
+
+	// Which headers are logged and which are masked. NewHTTPLogger sets up
+	// one that masks DefaultDeniedHeaders; nil logs every header as is.
+	HeaderPolicy *HeaderPolicy
+
+	// How the query string is logged. NewHTTPLogger sets up one that redacts
+	// DefaultRedactedQueryParams; nil logs the query as is, in the path.
+	QueryPolicy *QueryPolicy
+
+	// Log the basic auth or URL user name, when there is one, as the user
+	// field. NewHTTPLogger turns it on; turn it off where user names are
+	// sensitive, e.g. email addresses.
+	LogUser bool
+
+	// Picks the level Log (and so Middleware) logs at. NewHTTPLogger sets up
+	// one that logs 5xx at Error, 4xx at Warning and the rest at Info; nil
+	// logs everything at Info.
+	LevelPolicy *LevelPolicy
+}
+
+// DurationUnit controls how the request duration is logged.
+type DurationUnit int
+
+const (
+	// DurationMilliseconds logs a float number of milliseconds, e.g. 12.5
+	DurationMilliseconds DurationUnit = iota
+	// DurationNanoseconds logs an integer number of nanoseconds, e.g. 12500000
+	DurationNanoseconds
+	// DurationString logs a Go duration string, e.g. "12.5ms"
+	DurationString
+)
+
+func (unit DurationUnit) format(d time.Duration) interface{} {
+	switch unit {
+	case DurationNanoseconds:
+		return d.Nanoseconds()
+	case DurationString:
+		return d.String()
+	default:
+		return float64(d) / float64(time.Millisecond)
+	}
 }
 
 // NewHTTPLogger constructor
 func NewHTTPLogger(name string, destination LogDestination, formatter Formatter) (*HTTPLogger, error) {
-	return &HTTPLogger{name: name, formatter: formatter, destination: destination}, nil
+	if destination == nil {
+		return nil, errors.New(fmt.Sprintf("No destination given for logger %s", name))
+	}
+	if formatter == nil {
+		return nil, errors.New(fmt.Sprintf("No formatter given for logger %s", name))
+	}
+
+	log, err := newHTTPLogger(name)
+	if err != nil {
+		return nil, err
+	}
+	log.destination = &formattedDestination{log: log, formatter: formatter, destination: destination}
+	return log, nil
+}
+
+// Sets up the policies, leaving the destination to the constructors.
+func newHTTPLogger(name string) (*HTTPLogger, error) {
+	headerPolicy, err := NewHeaderPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	queryPolicy, err := NewQueryPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	levelPolicy, err := NewLevelPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPLogger{
+		name:         name,
+		HeaderPolicy: headerPolicy,
+		QueryPolicy:  queryPolicy,
+		LevelPolicy:  levelPolicy,
+		LogUser:      true,
+	}, nil
+}
+
+// Log logs at the level the LevelPolicy picks for the response status.
+func (log *HTTPLogger) Log(res http.ResponseWriter, req *http.Request) {
+	level := LevelInfo
+	if log.LevelPolicy != nil {
+		level = log.LevelPolicy.level(fetchStatus(res), req.URL.Path)
+	}
+
+	log.write(level, res, req)
 }
 
 func (log *HTTPLogger) Debug(res http.ResponseWriter, req *http.Request) {
-	data, _ := log.format(res, req)
-	log.destination.Debug(data)
+	log.write(LevelDebug, res, req)
 }
 
 func (log *HTTPLogger) Info(res http.ResponseWriter, req *http.Request) {
-	data, _ := log.format(res, req)
-	log.destination.Info(data)
+	log.write(LevelInfo, res, req)
 }
 
 func (log *HTTPLogger) Warning(res http.ResponseWriter, req *http.Request) {
-	data, _ := log.format(res, req)
-	log.destination.Warning(data)
+	log.write(LevelWarning, res, req)
 }
 
 func (log *HTTPLogger) Error(res http.ResponseWriter, req *http.Request) {
-	data, _ := log.format(res, req)
-	log.destination.Error(data)
+	log.write(LevelError, res, req)
 }
 
 func (log *HTTPLogger) Critical(res http.ResponseWriter, req *http.Request) {
-	data, _ := log.format(res, req)
-	log.destination.Critical(data)
+	log.write(LevelCritical, res, req)
 }
 
 // HTTP request fields that should be logged.
-// If you want to log more information then add it here before setting it in
-// the Log method.
+// Fields that make sense for every request belong here; anything specific to
+// an application should come from a FieldExtractor and ends up in Extra.
 type fields struct {
-	Method  string              `json:"method"`
-	Status  string              `json:"status"`
-	Path    string              `json:"path"`
-	Host    string              `json:"host"`
-	Headers map[string][]string `json:"headers"`
+	Method   string              `json:"method"`
+	Status   string              `json:"status"`
+	Path     string              `json:"path"`
+	Host     string              `json:"host"`
+	Query    map[string][]string `json:"query,omitempty"`
+	Headers  map[string][]string `json:"headers"`
+	BytesIn  int64               `json:"bytes_in"`
+	BytesOut int64               `json:"bytes_out"`
+	Duration interface{}         `json:"duration,omitempty"`
+
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	User       string `json:"user,omitempty"`
+	Referer    string `json:"referer,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+
+	// Custom fields, merged in alongside the others by MarshalJSON and String.
+	Extra map[string]interface{} `json:"-"`
+
+	// When the request started, or was logged if that's unknown.
+	started time.Time
+	// Unformatted Duration, or 0 if that's unknown.
+	elapsed time.Duration
+	// Level the record is logged at, for formatters that include it.
+	level Level
 }
 
-func newFields(res http.ResponseWriter, req *http.Request) *fields {
+func (log *HTTPLogger) newFields(res http.ResponseWriter, req *http.Request) *fields {
 	// If you need to add to the @fields key, add it here
-	return &fields{
-		Method:  req.Method,
-		Status:  fetchStatusCode(res), // Only fetches if Status() is defined on res
-		Path:    req.URL.RequestURI(),
-		Headers: map[string][]string(req.Header),
-		Host:    req.Host,
+	f := &fields{
+		Method:   req.Method,
+		Status:   fetchStatusCode(res), // Only fetches if Status() is defined on res
+		Path:     req.URL.RequestURI(),
+		Headers:  map[string][]string(req.Header),
+		Host:     req.Host,
+		BytesIn:  req.ContentLength,
+		BytesOut: fetchBytesWritten(res), // Only fetches if BytesWritten() is defined on res
+
+		RemoteAddr: req.RemoteAddr,
+		Protocol:   req.Proto,
+
+		started: time.Now(),
+	}
+
+	if log.LogUser {
+		f.User = fetchUser(req)
+	}
+
+	if log.HeaderPolicy != nil {
+		f.Headers = log.HeaderPolicy.apply(req.Header)
+	}
+
+	if log.QueryPolicy != nil {
+		log.QueryPolicy.apply(f, req.URL.RequestURI())
+		f.Headers = log.QueryPolicy.redactReferer(f.Headers)
 	}
+
+	// Taken from the filtered headers, so the policies apply to them too
+	f.Referer = http.Header(f.Headers).Get("Referer")
+	f.UserAgent = http.Header(f.Headers).Get("User-Agent")
+
+	log.extractFields(f, res, req)
+
+	// Only set when the request came through Middleware
+	state, ok := req.Context().Value(stateKey).(*requestState)
+	if ok {
+		f.started = state.start
+		f.elapsed = time.Since(state.start)
+		f.Duration = log.DurationUnit.format(f.elapsed)
+		if f.BytesIn < 0 && state.body != nil {
+			f.BytesIn = state.body.n
+		}
+		state.mergeInto(f, log.DurationUnit)
+	}
+
+	if f.BytesIn < 0 {
+		f.BytesIn = 0
+	}
+
+	return f
 }
 
 // Attempts to see if the passed type implements a Status() method.
 // If so, it is called and the value is returned.
 // See: https://groups.google.com/forum/#!topic/golang-nuts/gz4iBqPcLt8
 func fetchStatusCode(res http.ResponseWriter) string {
-	var statusCode int
+	statusCode := fetchStatus(res)
+	if statusCode == 0 {
+		return ""
+	}
 
+	return strconv.Itoa(statusCode)
+}
+
+// Same as fetchStatusCode, but returns 0 rather than "" when unknown.
+func fetchStatus(res http.ResponseWriter) int {
 	type statusInterface interface {
 		Status() int
 	}
 
 	statusCaller, ok := res.(statusInterface)
+	if !ok {
+		return 0
+	}
+
+	return statusCaller.Status()
+}
+
+// The user name from basic auth or the URL, if there is one. Never the
+// password.
+func fetchUser(req *http.Request) string {
+	user, _, ok := req.BasicAuth()
 	if ok {
-		statusCode = statusCaller.Status()
+		return user
 	}
 
-	if statusCode == 0 {
-		return ""
+	if req.URL.User != nil {
+		return req.URL.User.Username()
 	}
 
-	return strconv.Itoa(statusCode)
+	return ""
 }
 
-// Creates new fields and formats using the set formatter.
-func (log *HTTPLogger) format(res http.ResponseWriter, req *http.Request) (string, error) {
-	return log.formatter.Format(newFields(res, req))
+// Same as fetchStatusCode, but for a BytesWritten() method.
+func fetchBytesWritten(res http.ResponseWriter) int64 {
+	type bytesInterface interface {
+		BytesWritten() int64
+	}
+
+	bytesCaller, ok := res.(bytesInterface)
+	if !ok {
+		return 0
+	}
+
+	return bytesCaller.BytesWritten()
+}
+
+// Creates new fields and hands them to the destination as a record at the
+// given level.
+func (log *HTTPLogger) write(level Level, res http.ResponseWriter, req *http.Request) {
+	f := log.newFields(res, req)
+	f.level = level
+
+	record := newRecord(f, req)
+	if _, ok := log.destination.(fieldsDestination); !ok {
+		record.Attrs = newAttrs(f)
+	}
+	log.destination.LogRecord(record)
 }
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -82,6 +83,25 @@ func TestLogger_fields(t *testing.T) {
 	}
 }
 
+func TestLogger_user(t *testing.T) {
+	formatter, _ := NewCommonLogFormatter()
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req := httptest.NewRequest("GET", "http://www.foo.com/", nil)
+	req.SetBasicAuth("jane@example.com", "s3cr3t")
+
+	logger.Info(httptest.NewRecorder(), req)
+	logger.LogUser = false
+	logger.Info(httptest.NewRecorder(), req)
+
+	first, second := memBackend.Head().Record.Message(), memBackend.Head().Next().Record.Message()
+	if !strings.HasPrefix(first, "192.0.2.1 - jane@example.com [") {
+		t.Error("User not logged by default, got", first)
+	}
+	if !strings.HasPrefix(second, "192.0.2.1 - - [") {
+		t.Error("User logged with LogUser off, got", second)
+	}
+}
+
 func TestServerLogger_responseRecorderWithoutStatusMethod(t *testing.T) {
 	memBackend, logger := loadLogger()
 	res, req := createRequestAndResponse()
@@ -99,6 +119,68 @@ func TestServerLogger_responseRecorderWithoutStatusMethod(t *testing.T) {
 	}
 }
 
+func TestServerLogger_percentSignsInLogStashRecord(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createPercentRequestAndResponse()
+
+	logger.Info(res, req)
+	lastWrite := memBackend.Head().Record.Message()
+
+	m, err := decodeJSONToMap(lastWrite)
+	if err != nil {
+		t.Fatal("Record is not valid JSON:", lastWrite)
+	}
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["path"] != "/search/100%25?q=100%25&fmt=%s%d" {
+		t.Errorf("@fields['path'] not set correctly, expected %q, got %q", "/search/100%25?q=100%25&fmt=%s%d", fields["path"])
+	}
+
+	headers := fields["headers"].(map[string]interface{})
+	if !reflect.DeepEqual(headers["X-Discount"], []interface{}{"50% off %v"}) {
+		t.Errorf("Header X-Discount not set correctly, expected %q got %q", "50% off %v", headers["X-Discount"])
+	}
+}
+
+func TestServerLogger_percentSignsInTextRecord(t *testing.T) {
+	formatter, _ := NewTextFormatter("testApp")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	res, req := createPercentRequestAndResponse()
+
+	for _, level := range []func(http.ResponseWriter, *http.Request){
+		logger.Debug, logger.Info, logger.Warning, logger.Error, logger.Critical,
+	} {
+		level(res, req)
+	}
+
+	for node := memBackend.Head(); node != nil; node = node.Next() {
+		lastWrite := node.Record.Message()
+
+		if strings.Contains(lastWrite, "%!") {
+			t.Error("Record contains a formatting error:", lastWrite)
+		}
+
+		if !strings.Contains(lastWrite, "Path: /search/100%25?q=100%25&fmt=%s%d") {
+			t.Error("Path not logged verbatim:", lastWrite)
+		}
+
+		if !strings.Contains(lastWrite, "X-Discount:[50% off %v]") {
+			t.Error("Header not logged verbatim:", lastWrite)
+		}
+	}
+}
+
+func TestNewHTTPLogger_missingArguments(t *testing.T) {
+	formatter, _ := NewTextFormatter("foo")
+	if _, err := NewHTTPLogger("foo", nil, formatter); err == nil {
+		t.Error("Expected an error creating a logger without a destination")
+	}
+
+	if _, err := NewHTTPLogger("foo", golog.MustGetLogger("test"), nil); err == nil {
+		t.Error("Expected an error creating a logger without a formatter")
+	}
+}
+
 // *************************************
 // Helper functions
 // *************************************
@@ -113,8 +195,12 @@ func contains(s []int, e int) bool {
 }
 
 func loadLogger() (*golog.MemoryBackend, *HTTPLogger) {
-	log := golog.MustGetLogger("test")
 	formatter, _ := NewLogStashFormatter("fooApp", []string{"blimp", "foo"})
+	return loadLoggerWithFormatter(formatter)
+}
+
+func loadLoggerWithFormatter(formatter Formatter) (*golog.MemoryBackend, *HTTPLogger) {
+	log := golog.MustGetLogger("test")
 
 	// Setup one stdout and one syslog backend.
 	memBackend := golog.NewMemoryBackend(1024)
@@ -135,6 +221,14 @@ func createRequestAndResponse() (http.ResponseWriter, *http.Request) {
 	return res, req
 }
 
+func createPercentRequestAndResponse() (http.ResponseWriter, *http.Request) {
+	uri := "http://www.foo.com/search/100%25?q=100%25&fmt=%s%d"
+	req, _ := http.NewRequest("GET", uri, nil)
+	req.Header.Add("X-Discount", "50% off %v")
+	res := httptest.NewRecorder()
+	return res, req
+}
+
 // // Implement our own wrapper to set and fetch status code
 type wrappedRecorder struct {
 	*httptest.ResponseRecorder
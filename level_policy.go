@@ -0,0 +1,78 @@
+package httpclerk
+
+// Level of a log record, named after the LogDestination methods.
+type Level int
+
+const (
+	// LevelUnset is the zero Level, for a LevelPolicy field left to its default.
+	LevelUnset Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelCritical
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"}
+
+func (level Level) String() string {
+	if level < LevelDebug || level > LevelCritical {
+		return "INFO"
+	}
+	return levelNames[level-LevelDebug]
+}
+
+// LevelPolicy picks the level HTTPLogger.Log logs a request at from its
+// response status, so callers don't have to choose before they know the
+// outcome.
+type LevelPolicy struct {
+	// Levels for request paths, e.g. {"/healthz": LevelDebug}. These take
+	// precedence over everything else.
+	Paths map[string]Level
+	// Levels for specific status codes, e.g. {http.StatusNotFound: LevelInfo}
+	Statuses map[int]Level
+	// Level for any other 5xx response. Defaults to LevelError.
+	ServerError Level
+	// Level for any other 4xx response. Defaults to LevelWarning.
+	ClientError Level
+	// Level for everything else, including an unknown status. Defaults to LevelInfo.
+	Default Level
+}
+
+// NewLevelPolicy constructor, logging 5xx at Error, 4xx at Warning and
+// everything else at Info.
+func NewLevelPolicy() (*LevelPolicy, error) {
+	return &LevelPolicy{
+		Paths:       map[string]Level{},
+		Statuses:    map[int]Level{},
+		ServerError: LevelError,
+		ClientError: LevelWarning,
+		Default:     LevelInfo,
+	}, nil
+}
+
+func (policy *LevelPolicy) level(status int, path string) Level {
+	if level, ok := policy.Paths[path]; ok {
+		return level
+	}
+
+	if level, ok := policy.Statuses[status]; ok {
+		return level
+	}
+
+	switch {
+	case status >= 500 && status <= 599:
+		return orLevel(policy.ServerError, LevelError)
+	case status >= 400 && status <= 499:
+		return orLevel(policy.ClientError, LevelWarning)
+	default:
+		return orLevel(policy.Default, LevelInfo)
+	}
+}
+
+func orLevel(level, fallback Level) Level {
+	if level == LevelUnset {
+		return fallback
+	}
+	return level
+}
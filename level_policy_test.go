@@ -0,0 +1,112 @@
+package httpclerk
+
+import (
+	golog "github.com/op/go-logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func loggedLevel(configure func(*HTTPLogger), status int, uri string) golog.Level {
+	memBackend, logger := loadLogger()
+	configure(logger)
+	req, _ := http.NewRequest("GET", uri, nil)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	return memBackend.Head().Record.Level
+}
+
+func TestLevelPolicy_defaults(t *testing.T) {
+	noop := func(logger *HTTPLogger) {}
+
+	cases := map[int]golog.Level{
+		http.StatusOK:                  golog.INFO,
+		http.StatusFound:               golog.INFO,
+		http.StatusNotFound:            golog.WARNING,
+		http.StatusInternalServerError: golog.ERROR,
+		http.StatusServiceUnavailable:  golog.ERROR,
+	}
+
+	for status, expected := range cases {
+		if level := loggedLevel(noop, status, "http://www.foo.com/"); level != expected {
+			t.Error("Status", status, "not logged at the right level, expected", expected, "got", level)
+		}
+	}
+}
+
+func TestLevelPolicy_overrides(t *testing.T) {
+	configure := func(logger *HTTPLogger) {
+		logger.LevelPolicy.Paths["/healthz"] = LevelDebug
+		logger.LevelPolicy.Statuses[http.StatusNotFound] = LevelInfo
+		logger.LevelPolicy.ServerError = LevelCritical
+	}
+
+	if level := loggedLevel(configure, http.StatusServiceUnavailable, "http://www.foo.com/healthz?full=1"); level != golog.DEBUG {
+		t.Error("Health check not logged at DEBUG, got", level)
+	}
+
+	if level := loggedLevel(configure, http.StatusNotFound, "http://www.foo.com/missing"); level != golog.INFO {
+		t.Error("404 not logged at INFO, got", level)
+	}
+
+	if level := loggedLevel(configure, http.StatusBadGateway, "http://www.foo.com/"); level != golog.CRITICAL {
+		t.Error("502 not logged at CRITICAL, got", level)
+	}
+}
+
+func TestLevelPolicy_literal(t *testing.T) {
+	policy := &LevelPolicy{Paths: map[string]Level{"/healthz": LevelDebug}}
+
+	cases := map[int]Level{
+		http.StatusInternalServerError: LevelError,
+		http.StatusNotFound:            LevelWarning,
+		http.StatusOK:                  LevelInfo,
+	}
+
+	for status, expected := range cases {
+		if level := policy.level(status, "/"); level != expected {
+			t.Error("Status", status, "not given the right level, expected", expected, "got", level)
+		}
+	}
+
+	policy = &LevelPolicy{ServerError: LevelDebug, ClientError: LevelDebug, Default: LevelDebug}
+	for status := range cases {
+		if level := policy.level(status, "/"); level != LevelDebug {
+			t.Error("Status", status, "not logged at DEBUG when set, got", level)
+		}
+	}
+}
+
+func TestLevelPolicy_disabled(t *testing.T) {
+	level := loggedLevel(func(logger *HTTPLogger) {
+		logger.LevelPolicy = nil
+	}, http.StatusInternalServerError, "http://www.foo.com/")
+
+	if level != golog.INFO {
+		t.Error("Record not logged at INFO without a policy, got", level)
+	}
+}
+
+func TestLog_unknownStatus(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	logger.Log(res, req)
+
+	if level := memBackend.Head().Record.Level; level != golog.INFO {
+		t.Error("Record with an unknown status not logged at INFO, got", level)
+	}
+}
+
+func TestLevel_String(t *testing.T) {
+	if LevelWarning.String() != "WARNING" {
+		t.Error("Level not named correctly, expected WARNING, got", LevelWarning.String())
+	}
+	if LevelDebug.String() != "DEBUG" || LevelUnset.String() != "INFO" {
+		t.Error("Level not named correctly, got", LevelDebug.String(), LevelUnset.String())
+	}
+}
@@ -0,0 +1,150 @@
+package httpclerk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LogfmtFormatter formats records as logfmt key=value pairs, as expected by
+// Heroku-style pipelines and Loki:
+//
+//	app=myApp method=GET status=200 path=/foo host=www.foo.com header.user_agent=curl/7.30.0 bytes_in=0 ...
+//
+// Keys always come in the same order: built-in fields first, then custom
+// fields sorted by key. Headers, the structured query and other nested
+// values are flattened to dotted keys such as header.x_foo.
+type LogfmtFormatter struct {
+	// Logged first as app=..., when set.
+	AppName string
+}
+
+// NewLogfmtFormatter constructor
+func NewLogfmtFormatter(appName string) (*LogfmtFormatter, error) {
+	return &LogfmtFormatter{AppName: appName}, nil
+}
+
+func (formatter *LogfmtFormatter) Format(customFields interface{}) (string, error) {
+	var kvs []keyValue
+	if formatter.AppName != "" {
+		kvs = append(kvs, keyValue{"app", formatter.AppName})
+	}
+
+	f, ok := customFields.(*fields)
+	if ok {
+		kvs = append(kvs, f.keyValues()...)
+	} else {
+		// Go through JSON for anything else, for its keys
+		m, err := toJSONMap(customFields)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range sortedKeys(m) {
+			kvs = append(kvs, keyValue{key, m[key]})
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, kv := range kvs {
+		headers, ok := kv.value.(map[string][]string)
+		if ok && kv.key == "headers" {
+			for _, name := range sortedStringSliceKeys(headers) {
+				writeLogfmt(&buf, "header."+logfmtKey(strings.ToLower(name)), strings.Join(headers[name], ", "))
+			}
+			continue
+		}
+		writeLogfmt(&buf, logfmtKey(kv.key), kv.value)
+	}
+
+	return buf.String(), nil
+}
+
+func writeLogfmt(buf *bytes.Buffer, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string][]string:
+		for _, name := range sortedStringSliceKeys(v) {
+			writeLogfmt(buf, key+"."+logfmtKey(name), strings.Join(v[name], ", "))
+		}
+	case map[string]interface{}:
+		for _, name := range sortedKeys(v) {
+			writeLogfmt(buf, key+"."+logfmtKey(name), v[name])
+		}
+	default:
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+}
+
+// Replaces anything that isn't safe in a key by '_', so x-foo becomes x_foo.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		s = v
+	case []string:
+		s = strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int, int64, bool:
+		return fmt.Sprint(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if s == "" {
+		return `""`
+	}
+	if strings.IndexFunc(s, needsLogfmtQuotes) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsLogfmtQuotes(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f
+}
+
+func sortedStringSliceKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Marshals anything to a JSON object and back, for formatters that work on
+// keys and values.
+func toJSONMap(data interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	var m map[string]interface{}
+	err = json.Unmarshal(encoded, &m)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Can only format values that marshal to a JSON object: %s", err))
+	}
+
+	return m, nil
+}
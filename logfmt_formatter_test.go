@@ -0,0 +1,119 @@
+package httpclerk
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtFormat(t *testing.T) {
+	formatter, _ := NewLogfmtFormatter("testApp")
+
+	fields := &fields{
+		Method:   "GET",
+		Status:   "200",
+		Path:     "/search?q=a b",
+		Host:     "dill.on.com",
+		Headers:  map[string][]string{"X-Foo": []string{"Gaz"}, "Accept": []string{"text/html", "*/*"}},
+		BytesIn:  12,
+		BytesOut: 345,
+		Duration: 6.5,
+		Extra: map[string]interface{}{
+			"tenant":  `acme "inc"`,
+			"timings": map[string]interface{}{"db": 1.25},
+			"beta":    true,
+		},
+	}
+
+	data, err := formatter.Format(fields)
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	expected := `app=testApp method=GET status=200 path="/search?q=a b" host=dill.on.com ` +
+		`header.accept="text/html, */*" header.x_foo=Gaz bytes_in=12 bytes_out=345 duration=6.5 ` +
+		`beta=true tenant="acme \"inc\"" timings.db=1.25`
+	if data != expected {
+		t.Error("Fields not formatted correctly, expected\n", expected, "\ngot\n", data)
+	}
+}
+
+func TestLogfmtFormat_escaping(t *testing.T) {
+	formatter, _ := NewLogfmtFormatter("")
+
+	fields := &fields{
+		Method: "GET",
+		Path:   "/a=b",
+		Extra: map[string]interface{}{
+			"user name": "line\nbreak",
+			"empty":     "",
+			"back":      `c:\temp`,
+		},
+	}
+
+	data, _ := formatter.Format(fields)
+
+	for _, pair := range []string{`method=GET `, `status="" `, `path="/a=b" `, `user_name="line\nbreak"`, `empty=""`, `back="c:\\temp"`} {
+		if !strings.Contains(data, pair) {
+			t.Error("Expected", pair, "in", data)
+		}
+	}
+
+	if strings.HasPrefix(data, "app=") {
+		t.Error("app logged without an AppName, got", data)
+	}
+}
+
+func TestLogfmtFormat_stableOrder(t *testing.T) {
+	formatter, _ := NewLogfmtFormatter("testApp")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	res, req := createRequestAndResponse()
+	for _, name := range []string{"X-B", "X-A", "X-C", "X-E", "X-D"} {
+		req.Header.Set(name, "1")
+	}
+
+	for i := 0; i < 20; i++ {
+		logger.Info(res, req)
+	}
+
+	first := memBackend.Head().Record.Message()
+	for node := memBackend.Head(); node != nil; node = node.Next() {
+		if node.Record.Message() != first {
+			t.Fatal("Key order not stable, got\n", first, "\nand\n", node.Record.Message())
+		}
+	}
+
+	if !strings.Contains(first, "header.x_a=1 header.x_b=1 header.x_c=1 header.x_d=1 header.x_e=1 header.x_foo_header=Bar") {
+		t.Error("Headers not flattened in order, got", first)
+	}
+}
+
+func TestLogfmtFormat_otherValues(t *testing.T) {
+	formatter, _ := NewLogfmtFormatter("")
+
+	data, err := formatter.Format(&testingFields{"hi", "there", 101, []int{1, 2}})
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	if data != `Blip="[1 2]" Block=101 Chip=there Hot=hi` {
+		t.Error("Value not formatted correctly, got", data)
+	}
+
+	if _, err := formatter.Format([]string{"not", "an", "object"}); err == nil {
+		t.Error("Expected an error formatting something that isn't an object")
+	}
+}
+
+func TestLogfmtFormat_levelMethods(t *testing.T) {
+	formatter, _ := NewLogfmtFormatter("testApp")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req, _ := http.NewRequest("GET", "http://www.foo.com/100%25", nil)
+
+	logger.Warning(newWrappedRecorder(), req)
+
+	if !strings.HasPrefix(memBackend.Head().Record.Message(), "app=testApp method=GET status=200 path=/100%25 ") {
+		t.Error("Record not formatted correctly, got", memBackend.Head().Record.Message())
+	}
+}
@@ -0,0 +1,83 @@
+// Package logrusclerk logs httpclerk records to a logrus Logger.
+package logrusclerk
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/zendesk/go-httpclerk"
+)
+
+// Destination logs records to a logrus Logger.
+//
+// With httpclerk.NewHTTPLogger, it logs the formatted record as the message.
+// With httpclerk.NewStructuredHTTPLogger, the message is "METHOD PATH STATUS"
+// and the fields are logged as an "http" field, with nested values as maps.
+//
+// Critical records are logged at Error, as logrus's higher levels panic or
+// exit.
+type Destination struct {
+	logger *logrus.Logger
+}
+
+// NewDestination constructor.
+func NewDestination(logger *logrus.Logger) (*Destination, error) {
+	return &Destination{logger: logger}, nil
+}
+
+var levels = map[httpclerk.Level]logrus.Level{
+	httpclerk.LevelDebug:    logrus.DebugLevel,
+	httpclerk.LevelInfo:     logrus.InfoLevel,
+	httpclerk.LevelWarning:  logrus.WarnLevel,
+	httpclerk.LevelError:    logrus.ErrorLevel,
+	httpclerk.LevelCritical: logrus.ErrorLevel,
+}
+
+func (dest *Destination) Debug(data string, args ...interface{}) {
+	dest.log(logrus.DebugLevel, data, args)
+}
+
+func (dest *Destination) Info(data string, args ...interface{}) {
+	dest.log(logrus.InfoLevel, data, args)
+}
+
+func (dest *Destination) Warning(data string, args ...interface{}) {
+	dest.log(logrus.WarnLevel, data, args)
+}
+
+func (dest *Destination) Error(data string, args ...interface{}) {
+	dest.log(logrus.ErrorLevel, data, args)
+}
+
+func (dest *Destination) Critical(data string, args ...interface{}) {
+	dest.log(logrus.ErrorLevel, data, args)
+}
+
+// Like Logf, but without args the format is taken as is.
+func (dest *Destination) log(level logrus.Level, data string, args []interface{}) {
+	if len(args) == 0 {
+		dest.logger.Log(level, data)
+		return
+	}
+	dest.logger.Logf(level, data, args...)
+}
+
+func (dest *Destination) LogRecord(record httpclerk.Record) {
+	level := levels[record.Level]
+	if !dest.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	dest.logger.WithTime(record.Time).WithField("http", fieldMap(record.Attrs)).Log(level, record.Message)
+}
+
+// Groups, like headers and timings, become nested maps.
+func fieldMap(attrs []httpclerk.Attr) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		if group, ok := attr.Value.([]httpclerk.Attr); ok {
+			m[attr.Key] = fieldMap(group)
+			continue
+		}
+		m[attr.Key] = attr.Value
+	}
+	return m
+}
@@ -0,0 +1,139 @@
+package logrusclerk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zendesk/go-httpclerk"
+)
+
+func loadLogger(buf *bytes.Buffer) *logrus.Logger {
+	logger := logrus.New()
+	logger.Out = buf
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.Level = logrus.DebugLevel
+	return logger
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal("Error decoding logrus record", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestDestination(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewDestination(loadLogger(&buf))
+
+	dest.Debug("%s", "debug 100%")
+	dest.Info("%s", "info")
+	dest.Warning("%s", "warning")
+	dest.Error("%s", "error")
+	dest.Critical("%s", "critical")
+
+	records := decodeLines(t, &buf)
+	expected := [][]string{
+		{"debug", "debug 100%"},
+		{"info", "info"},
+		{"warning", "warning"},
+		{"error", "error"},
+		{"error", "critical"},
+	}
+	for i, record := range records {
+		if record["level"] != expected[i][0] || record["msg"] != expected[i][1] {
+			t.Error("Record not logged correctly, expected", expected[i], "got", record)
+		}
+	}
+}
+
+func TestDestination_formatted(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewDestination(loadLogger(&buf))
+	formatter, _ := httpclerk.NewTextFormatter("foo")
+	logger, _ := httpclerk.NewHTTPLogger("foo", dest, formatter)
+	req, _ := http.NewRequest("PUT", "http://www.foo.com/1234.json", nil)
+
+	logger.Warning(httptest.NewRecorder(), req)
+
+	record := decodeLines(t, &buf)[0]
+	if record["level"] != "warning" || !strings.Contains(record["msg"].(string), "Method: PUT Path: /1234.json ") {
+		t.Error("Formatted record not logged as the message, got", record)
+	}
+}
+
+func TestDestination_structured(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewDestination(loadLogger(&buf))
+	logger, _ := httpclerk.NewStructuredHTTPLogger("foo", dest)
+	req := httptest.NewRequest("GET", "http://www.foo.com/items?token=abc", nil)
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	before := time.Now().Truncate(time.Second)
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpclerk.AddField(r.Context(), "tenant", "acme")
+		httpclerk.AddTiming(r.Context(), "db", 2*time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := decodeLines(t, &buf)[0]
+	if record["level"] != "error" || record["msg"] != "GET /items?token=REDACTED 503" {
+		t.Error("Record not logged correctly, got", record)
+	}
+
+	logged, err := time.Parse(time.RFC3339, record["time"].(string))
+	if err != nil || logged.Before(before) || logged.After(time.Now()) {
+		t.Error("Time not set to the request's start, got", record["time"])
+	}
+
+	fields, ok := record["http"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Fields not logged as an http field, got", record)
+	}
+
+	expected := map[string]interface{}{
+		"method":      "GET",
+		"status":      "503",
+		"path":        "/items?token=REDACTED",
+		"host":        "www.foo.com",
+		"remote_addr": "192.0.2.1:1234",
+		"tenant":      "acme",
+		"headers":     map[string]interface{}{"User-Agent": []interface{}{"curl/7.30.0"}},
+		"timings":     map[string]interface{}{"db": 2.0},
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(fields[key], value) {
+			t.Errorf("Field %s not logged correctly, expected %#v got %#v", key, value, fields[key])
+		}
+	}
+}
+
+func TestDestination_structuredLevelDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	logrusLogger := loadLogger(&buf)
+	logrusLogger.Level = logrus.WarnLevel
+	dest, _ := NewDestination(logrusLogger)
+	logger, _ := httpclerk.NewStructuredHTTPLogger("foo", dest)
+	req, _ := http.NewRequest("GET", "http://www.foo.com/", nil)
+
+	logger.Info(httptest.NewRecorder(), req)
+	logger.Critical(httptest.NewRecorder(), req)
+
+	records := decodeLines(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "error" {
+		t.Error("Only the Critical record expected, got", records)
+	}
+}
@@ -4,16 +4,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 )
 
+// LogStashSchema is the version of the Logstash event layout to emit.
+type LogStashSchema int
+
+const (
+	// LogStashV0 nests fields under @fields, next to @source and @tags.
+	LogStashV0 LogStashSchema = iota
+	// LogStashV1 puts fields at the top level, next to @timestamp,
+	// @version, message, host, tags and source, as the json_lines codec
+	// expects.
+	LogStashV1
+)
+
+// LogStashCollision decides what happens to a field named like one of the
+// keys reserved by the v1 schema, such as the request's host field.
+type LogStashCollision int
+
+const (
+	// CollisionRename prefixes the field name with CollisionPrefix.
+	CollisionRename LogStashCollision = iota
+	// CollisionOverwrite lets the field replace the reserved key.
+	CollisionOverwrite
+	// CollisionDrop leaves the field out.
+	CollisionDrop
+	// CollisionError fails to format the record.
+	CollisionError
+)
+
+// Keys a v1 event sets itself.
+var logStashV1Reserved = []string{"@timestamp", "@version", "message", "host", "tags", "source"}
+
 type LogStashFormatter struct {
 	Source string
 	Tags   []string
+	Schema LogStashSchema
+	// Only used by LogStashV1.
+	Collisions LogStashCollision
+	// Prepended to colliding field names by CollisionRename. Defaults to "http_".
+	CollisionPrefix string
 }
 
-func NewLogStashFormatter(source string, tags []string) (*LogStashFormatter, error) {
-	return &LogStashFormatter{Source: source, Tags: tags}, nil
+// NewLogStashFormatter constructor. Formats LogStashV0 events unless another
+// schema is given.
+func NewLogStashFormatter(source string, tags []string, schema ...LogStashSchema) (*LogStashFormatter, error) {
+	formatter := &LogStashFormatter{Source: source, Tags: tags, CollisionPrefix: "http_"}
+	if len(schema) > 0 {
+		formatter.Schema = schema[0]
+	}
+	return formatter, nil
 }
 
 type LogStashJSON struct {
@@ -24,6 +67,18 @@ type LogStashJSON struct {
 }
 
 func (formatter *LogStashFormatter) Format(customFields interface{}) (string, error) {
+	if formatter.Schema == LogStashV1 {
+		return formatter.formatV1(customFields)
+	}
+
+	// @fields keeps the keys it had before the request details were added
+	// for the other formatters, so existing Logstash pipelines see no change.
+	if f, ok := customFields.(*fields); ok {
+		legacy := *f
+		legacy.RemoteAddr, legacy.Protocol, legacy.User, legacy.Referer, legacy.UserAgent = "", "", "", "", ""
+		customFields = &legacy
+	}
+
 	stash := &LogStashJSON{
 		Source:    formatter.Source,
 		Fields:    customFields,
@@ -38,3 +93,67 @@ func (formatter *LogStashFormatter) Format(customFields interface{}) (string, er
 
 	return string(data), nil
 }
+
+func (formatter *LogStashFormatter) formatV1(customFields interface{}) (string, error) {
+	event, err := toJSONMap(customFields)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range logStashV1Reserved {
+		value, ok := event[key]
+		if !ok {
+			continue
+		}
+
+		switch formatter.Collisions {
+		case CollisionOverwrite:
+			continue
+		case CollisionDrop:
+		case CollisionError:
+			return "", errors.New(fmt.Sprintf("Field %q is reserved by the Logstash v1 schema", key))
+		default:
+			prefix := formatter.CollisionPrefix
+			if prefix == "" {
+				prefix = "http_"
+			}
+			event[prefix+key] = value
+		}
+		delete(event, key)
+	}
+
+	set := func(key string, value interface{}) {
+		if _, ok := event[key]; !ok {
+			event[key] = value
+		}
+	}
+
+	host, _ := os.Hostname()
+	tags := formatter.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	set("@timestamp", time.Now().Format(time.RFC3339Nano))
+	set("@version", "1")
+	set("message", logStashMessage(customFields))
+	set("host", host)
+	set("tags", tags)
+	set("source", formatter.Source)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	return string(data), nil
+}
+
+// A short summary of the record, e.g. "GET /foo 200".
+func logStashMessage(customFields interface{}) string {
+	f, ok := customFields.(*fields)
+	if !ok {
+		return fmt.Sprint(customFields)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", f.Method, f.Path, f.Status))
+}
@@ -1,8 +1,11 @@
 package httpclerk
 
 import (
+	"net/http/httptest"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type testingFields struct {
@@ -37,3 +40,96 @@ func TestLogstashFormat(t *testing.T) {
 		t.Error("@source not set correctly, expected 'fooApp', got", m["@source"])
 	}
 }
+
+func TestLogstashFormat_requestDetails(t *testing.T) {
+	memBackend, logger := loadLogger()
+	req := httptest.NewRequest("GET", "http://www.foo.com/", nil)
+	req.SetBasicAuth("jane@example.com", "s3cr3t")
+	req.Header.Set("Referer", "http://www.foo.com/start")
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	logger.Info(httptest.NewRecorder(), req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+	for _, key := range []string{"remote_addr", "protocol", "user", "referer", "user_agent"} {
+		if _, ok := fields[key]; ok {
+			t.Error("@fields['"+key+"'] added to the v0 layout, got", fields[key])
+		}
+	}
+}
+
+func TestLogstashFormat_v1(t *testing.T) {
+	formatter, _ := NewLogStashFormatter("fooApp", []string{"blimp", "foo"}, LogStashV1)
+	hostname, _ := os.Hostname()
+
+	fields := &fields{Method: "GET", Status: "200", Path: "/foo", Host: "www.foo.com", Extra: map[string]interface{}{"tenant": "acme"}}
+	data, err := formatter.Format(fields)
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	m, _ := decodeJSONToMap(data)
+
+	expected := map[string]interface{}{
+		"@version":  "1",
+		"message":   "GET /foo 200",
+		"host":      hostname,
+		"source":    "fooApp",
+		"method":    "GET",
+		"status":    "200",
+		"tenant":    "acme",
+		"http_host": "www.foo.com",
+	}
+	for key, value := range expected {
+		if m[key] != value {
+			t.Error(key, "not set correctly, expected", value, "got", m[key])
+		}
+	}
+
+	if !reflect.DeepEqual(m["tags"], []interface{}{"blimp", "foo"}) {
+		t.Error("tags not set correctly, expected [blimp foo], got", m["tags"])
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, m["@timestamp"].(string)); err != nil {
+		t.Error("@timestamp not set correctly, expected time.RFC3339Nano, got", m["@timestamp"])
+	}
+
+	for _, key := range []string{"@fields", "@source", "@tags"} {
+		if _, ok := m[key]; ok {
+			t.Error(key, "set in a v1 event")
+		}
+	}
+}
+
+func TestLogstashFormat_v1Collisions(t *testing.T) {
+	fields := &fields{Method: "GET", Host: "www.foo.com", Extra: map[string]interface{}{"message": "hi"}}
+	hostname, _ := os.Hostname()
+
+	formatter, _ := NewLogStashFormatter("fooApp", nil, LogStashV1)
+	formatter.CollisionPrefix = "req_"
+	data, _ := formatter.Format(fields)
+	m, _ := decodeJSONToMap(data)
+	if m["req_host"] != "www.foo.com" || m["req_message"] != "hi" || m["host"] != hostname {
+		t.Error("Colliding fields not renamed, got", data)
+	}
+
+	formatter.Collisions = CollisionOverwrite
+	data, _ = formatter.Format(fields)
+	m, _ = decodeJSONToMap(data)
+	if m["host"] != "www.foo.com" || m["message"] != "hi" {
+		t.Error("Colliding fields not overwriting reserved keys, got", data)
+	}
+
+	formatter.Collisions = CollisionDrop
+	data, _ = formatter.Format(fields)
+	m, _ = decodeJSONToMap(data)
+	if m["host"] != hostname || m["message"] != "GET" || m["req_host"] != nil {
+		t.Error("Colliding fields not dropped, got", data)
+	}
+
+	formatter.Collisions = CollisionError
+	if _, err := formatter.Format(fields); err == nil {
+		t.Error("Expected an error formatting colliding fields")
+	}
+}
@@ -0,0 +1,75 @@
+package httpclerk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Middleware wraps next so that every request it serves is logged exactly
+// once, after next returns, using the logger's formatter and destination.
+// Records are logged through Log, at the level the LevelPolicy picks. A
+// request whose handler panics is logged with status 500, and the panic
+// carries on up to net/http.
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(":8080", clerk.Middleware(mux))
+func (log *HTTPLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		state := &requestState{start: time.Now()}
+		req = req.WithContext(context.WithValue(req.Context(), stateKey, state))
+		if req.Body != nil {
+			state.body = &countingReader{ReadCloser: req.Body}
+			req.Body = state.body
+		}
+
+		rec, _ := NewResponseRecorder(res)
+		defer func() {
+			// net/http aborts the response when a handler panics, whatever
+			// it wrote, so the request is logged as a 500 before panicking on.
+			if err := recover(); err != nil {
+				rec.status = http.StatusInternalServerError
+				log.Log(rec, req)
+				panic(err)
+			}
+			log.Log(rec, req)
+		}()
+
+		next.ServeHTTP(rec.Writer(), req)
+	})
+}
+
+// HandlerFunc is the http.HandlerFunc flavour of Middleware.
+func (log *HTTPLogger) HandlerFunc(next http.HandlerFunc) http.HandlerFunc {
+	return log.Middleware(next).ServeHTTP
+}
+
+type contextKey int
+
+const stateKey contextKey = 0
+
+// Bookkeeping Middleware hands to newFields through the request context.
+type requestState struct {
+	start time.Time
+	body  *countingReader
+
+	// Added by handlers through AddField and AddTiming, guarded by mu.
+	mu      sync.Mutex
+	fields  map[string]interface{}
+	timings map[string]time.Duration
+}
+
+// Counts the request body bytes read by the handler, for requests without a
+// Content-Length.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,200 @@
+package httpclerk
+
+import (
+	golog "github.com/op/go-logging"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_logsOncePerRequest(t *testing.T) {
+	memBackend, logger := loadLogger()
+	_, req := createRequestAndResponse()
+	res := httptest.NewRecorder()
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	handler.ServeHTTP(res, req)
+
+	if count := countRecords(memBackend); count != 1 {
+		t.Fatal("Expected exactly one log record, got", count)
+	}
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["status"] != "201" {
+		t.Error("@fields['status'] not set correctly, expected 201, got", fields["status"])
+	}
+
+	if fields["method"] != "PUT" {
+		t.Error("@fields['method'] not set correctly, expected PUT, got", fields["method"])
+	}
+
+	if res.Code != http.StatusCreated {
+		t.Error("Status not passed through to the response, expected 201, got", res.Code)
+	}
+}
+
+func TestMiddleware_defaultStatus(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	handler.ServeHTTP(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["status"] != "200" {
+		t.Error("@fields['status'] not set correctly, expected 200, got", fields["status"])
+	}
+}
+
+func TestMiddleware_panic(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if err := recover(); err != "boom" {
+				t.Error("Panic not passed on, got", err)
+			}
+		}()
+		handler.ServeHTTP(res, req)
+	}()
+
+	if count := countRecords(memBackend); count != 1 {
+		t.Fatal("Expected exactly one log record, got", count)
+	}
+
+	record := memBackend.Head().Record
+	m, _ := decodeJSONToMap(record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["status"] != "500" {
+		t.Error("@fields['status'] not set correctly, expected 500, got", fields["status"])
+	}
+
+	if record.Level != golog.ERROR {
+		t.Error("Panicking request not logged at Error, got", record.Level)
+	}
+}
+
+func TestHandlerFunc(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+
+	handler := logger.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler(res, req)
+
+	if count := countRecords(memBackend); count != 1 {
+		t.Fatal("Expected exactly one log record, got", count)
+	}
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["status"] != "404" {
+		t.Error("@fields['status'] not set correctly, expected 404, got", fields["status"])
+	}
+}
+
+func TestMiddleware_sizes(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "http://www.foo.com/upload", strings.NewReader("0123456789"))
+	req.ContentLength = -1 // As for a chunked upload
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte("hello"))
+		w.Write([]byte(" world"))
+	}))
+	handler.ServeHTTP(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["bytes_in"] != 10.0 {
+		t.Error("@fields['bytes_in'] not set correctly, expected 10, got", fields["bytes_in"])
+	}
+
+	if fields["bytes_out"] != 11.0 {
+		t.Error("@fields['bytes_out'] not set correctly, expected 11, got", fields["bytes_out"])
+	}
+}
+
+func TestMiddleware_contentLength(t *testing.T) {
+	memBackend, logger := loadLogger()
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "http://www.foo.com/upload", strings.NewReader("0123456789"))
+
+	// The handler never reads the body, so only Content-Length can tell
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+
+	if fields["bytes_in"] != 10.0 {
+		t.Error("@fields['bytes_in'] not set correctly, expected 10, got", fields["bytes_in"])
+	}
+}
+
+func TestMiddleware_durationUnits(t *testing.T) {
+	sleepy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+	})
+
+	memBackend, logger := loadLogger()
+	res, req := createRequestAndResponse()
+	logger.Middleware(sleepy).ServeHTTP(res, req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	ms, ok := m["@fields"].(map[string]interface{})["duration"].(float64)
+	if !ok || ms < 2 {
+		t.Error("@fields['duration'] not set correctly, expected at least 2ms, got", ms)
+	}
+
+	memBackend, logger = loadLogger()
+	logger.DurationUnit = DurationNanoseconds
+	logger.Middleware(sleepy).ServeHTTP(res, req)
+
+	m, _ = decodeJSONToMap(memBackend.Head().Record.Message())
+	ns, ok := m["@fields"].(map[string]interface{})["duration"].(float64)
+	if !ok || ns < float64(2*time.Millisecond) || ns != float64(int64(ns)) {
+		t.Error("@fields['duration'] not set correctly, expected integer nanoseconds, got", ns)
+	}
+
+	memBackend, logger = loadLogger()
+	logger.DurationUnit = DurationString
+	logger.Middleware(sleepy).ServeHTTP(res, req)
+
+	m, _ = decodeJSONToMap(memBackend.Head().Record.Message())
+	str, _ := m["@fields"].(map[string]interface{})["duration"].(string)
+	if d, err := time.ParseDuration(str); err != nil || d < 2*time.Millisecond {
+		t.Error("@fields['duration'] not set correctly, expected a duration string, got", str)
+	}
+}
+
+func countRecords(memBackend *golog.MemoryBackend) int {
+	count := 0
+	for node := memBackend.Head(); node != nil; node = node.Next() {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,252 @@
+package httpclerk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTelFormatter formats records as OTLP/JSON logs, ready for an
+// OpenTelemetry Collector. Each record is a complete export request holding
+// one LogRecord, whose attributes follow the OpenTelemetry HTTP semantic
+// conventions (http.request.method, http.response.status_code, url.path,
+// server.address and so on). Custom fields are added as attributes of the
+// same name.
+type OTelFormatter struct {
+	// Resource attributes, e.g. service.name and deployment.environment.
+	Resource map[string]interface{}
+	// Name of the instrumentation scope. Defaults to "github.com/zendesk/go-httpclerk".
+	ScopeName string
+}
+
+// NewOTelFormatter constructor, setting the service.name resource attribute.
+func NewOTelFormatter(serviceName string) (*OTelFormatter, error) {
+	return &OTelFormatter{Resource: map[string]interface{}{"service.name": serviceName}}, nil
+}
+
+// OpenTelemetry severity numbers for each level.
+var otelSeverities = map[Level]int{
+	LevelDebug:    5,
+	LevelInfo:     9,
+	LevelWarning:  13,
+	LevelError:    17,
+	LevelCritical: 21,
+}
+
+// The OTLP/JSON shapes, trimmed to what OTelFormatter writes.
+type otelLogs struct {
+	ResourceLogs []otelResourceLogs `json:"resourceLogs"`
+}
+
+type otelResourceLogs struct {
+	Resource  otelResource    `json:"resource"`
+	ScopeLogs []otelScopeLogs `json:"scopeLogs"`
+}
+
+type otelResource struct {
+	Attributes []otelKeyValue `json:"attributes"`
+}
+
+type otelScopeLogs struct {
+	Scope      otelScope       `json:"scope"`
+	LogRecords []otelLogRecord `json:"logRecords"`
+}
+
+type otelScope struct {
+	Name string `json:"name"`
+}
+
+type otelLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otelAnyValue   `json:"body"`
+	Attributes           []otelKeyValue `json:"attributes"`
+}
+
+type otelKeyValue struct {
+	Key   string       `json:"key"`
+	Value otelAnyValue `json:"value"`
+}
+
+// Exactly one of these is set. Integers are strings in OTLP/JSON.
+type otelAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otelArrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *otelKvlist     `json:"kvlistValue,omitempty"`
+}
+
+type otelArrayValue struct {
+	Values []otelAnyValue `json:"values"`
+}
+
+type otelKvlist struct {
+	Values []otelKeyValue `json:"values"`
+}
+
+func (formatter *OTelFormatter) Format(customFields interface{}) (string, error) {
+	now := time.Now()
+	record := otelLogRecord{
+		TimeUnixNano:         strconv.FormatInt(now.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+		SeverityNumber:       otelSeverities[LevelInfo],
+		SeverityText:         LevelInfo.String(),
+		Body:                 otelValue(logStashMessage(customFields)),
+		Attributes:           []otelKeyValue{},
+	}
+
+	f, ok := customFields.(*fields)
+	if ok {
+		record.TimeUnixNano = strconv.FormatInt(f.started.UnixNano(), 10)
+		record.SeverityNumber = otelSeverities[f.level]
+		record.SeverityText = f.level.String()
+		record.Attributes = otelAttributes(f)
+	} else {
+		m, err := toJSONMap(customFields)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range sortedKeys(m) {
+			record.Attributes = append(record.Attributes, otelKeyValue{key, otelValue(m[key])})
+		}
+	}
+
+	scopeName := formatter.ScopeName
+	if scopeName == "" {
+		scopeName = "github.com/zendesk/go-httpclerk"
+	}
+
+	resource := otelResource{Attributes: []otelKeyValue{}}
+	for _, key := range sortedKeys(formatter.Resource) {
+		resource.Attributes = append(resource.Attributes, otelKeyValue{key, otelValue(formatter.Resource[key])})
+	}
+
+	logs := otelLogs{ResourceLogs: []otelResourceLogs{{
+		Resource: resource,
+		ScopeLogs: []otelScopeLogs{{
+			Scope:      otelScope{Name: scopeName},
+			LogRecords: []otelLogRecord{record},
+		}},
+	}}}
+
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	return string(data), nil
+}
+
+func otelAttributes(f *fields) []otelKeyValue {
+	var attributes []otelKeyValue
+	add := func(key string, value interface{}) {
+		attributes = append(attributes, otelKeyValue{key, otelValue(value)})
+	}
+
+	add("http.request.method", f.Method)
+	if status, err := strconv.Atoi(f.Status); err == nil {
+		add("http.response.status_code", status)
+	}
+
+	path, query := f.Path, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	if f.Query != nil {
+		query = url.Values(f.Query).Encode()
+	}
+	add("url.path", path)
+	if query != "" {
+		add("url.query", query)
+	}
+
+	if host, port, err := net.SplitHostPort(f.Host); err == nil {
+		add("server.address", host)
+		if p, err := strconv.Atoi(port); err == nil {
+			add("server.port", p)
+		}
+	} else if f.Host != "" {
+		add("server.address", f.Host)
+	}
+
+	if host, port, err := net.SplitHostPort(f.RemoteAddr); err == nil {
+		add("client.address", host)
+		if p, err := strconv.Atoi(port); err == nil {
+			add("client.port", p)
+		}
+	}
+
+	if strings.HasPrefix(f.Protocol, "HTTP/") {
+		add("network.protocol.name", "http")
+		add("network.protocol.version", strings.TrimPrefix(f.Protocol, "HTTP/"))
+	}
+	if f.UserAgent != "" {
+		add("user_agent.original", f.UserAgent)
+	}
+	if f.User != "" {
+		add("user.name", f.User)
+	}
+
+	add("http.request.body.size", f.BytesIn)
+	add("http.response.body.size", f.BytesOut)
+	if f.elapsed > 0 {
+		add("http.server.request.duration", f.elapsed.Seconds())
+	}
+
+	for _, name := range sortedStringSliceKeys(f.Headers) {
+		add("http.request.header."+strings.ToLower(name), f.Headers[name])
+	}
+
+	for _, key := range sortedKeys(f.Extra) {
+		add(key, f.Extra[key])
+	}
+
+	return attributes
+}
+
+func otelValue(value interface{}) otelAnyValue {
+	switch v := value.(type) {
+	case string:
+		return otelAnyValue{StringValue: &v}
+	case bool:
+		return otelAnyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otelAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otelAnyValue{IntValue: &s}
+	case float64:
+		return otelAnyValue{DoubleValue: &v}
+	case []string:
+		array := &otelArrayValue{Values: []otelAnyValue{}}
+		for _, s := range v {
+			array.Values = append(array.Values, otelValue(s))
+		}
+		return otelAnyValue{ArrayValue: array}
+	case []interface{}:
+		array := &otelArrayValue{Values: []otelAnyValue{}}
+		for _, item := range v {
+			array.Values = append(array.Values, otelValue(item))
+		}
+		return otelAnyValue{ArrayValue: array}
+	case map[string]interface{}:
+		kvlist := &otelKvlist{Values: []otelKeyValue{}}
+		for _, key := range sortedKeys(v) {
+			kvlist.Values = append(kvlist.Values, otelKeyValue{key, otelValue(v[key])})
+		}
+		return otelAnyValue{KvlistValue: kvlist}
+	default:
+		s := fmt.Sprint(v)
+		return otelAnyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,143 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Finds the LogRecord in an OTLP/JSON export request.
+func otelRecord(t *testing.T, data string) (map[string]interface{}, map[string]interface{}) {
+	doc, err := decodeJSONToMap(data)
+	if err != nil {
+		t.Fatal("Error decoding OTLP/JSON", err)
+	}
+
+	resourceLogs := doc["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	scopeLogs := resourceLogs["scopeLogs"].([]interface{})[0].(map[string]interface{})
+	record := scopeLogs["logRecords"].([]interface{})[0].(map[string]interface{})
+	return resourceLogs, record
+}
+
+// Maps attribute keys to their AnyValue.
+func otelAttributeMap(attributes interface{}) map[string]map[string]interface{} {
+	m := map[string]map[string]interface{}{}
+	for _, attribute := range attributes.([]interface{}) {
+		kv := attribute.(map[string]interface{})
+		m[kv["key"].(string)] = kv["value"].(map[string]interface{})
+	}
+	return m
+}
+
+func TestOTelFormat(t *testing.T) {
+	formatter, _ := NewOTelFormatter("fooService")
+	formatter.Resource["deployment.environment"] = "staging"
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	req := httptest.NewRequest("POST", "http://www.foo.com:8080/items?page=2&token=abc", strings.NewReader("body"))
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	before := time.Now()
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	resourceLogs, record := otelRecord(t, memBackend.Head().Record.Message())
+
+	resource := otelAttributeMap(resourceLogs["resource"].(map[string]interface{})["attributes"])
+	if resource["service.name"]["stringValue"] != "fooService" || resource["deployment.environment"]["stringValue"] != "staging" {
+		t.Error("Resource attributes not set correctly, got", resource)
+	}
+
+	if record["severityNumber"] != 13.0 || record["severityText"] != "WARNING" {
+		t.Error("Severity not set from the level, got", record["severityNumber"], record["severityText"])
+	}
+
+	timestamp, err := strconv.ParseInt(record["timeUnixNano"].(string), 10, 64)
+	if err != nil || timestamp < before.UnixNano() || timestamp > time.Now().UnixNano() {
+		t.Error("timeUnixNano not set to the request's start, got", record["timeUnixNano"])
+	}
+
+	if record["body"].(map[string]interface{})["stringValue"] != "POST /items?page=2&token=REDACTED 404" {
+		t.Error("Body not set correctly, got", record["body"])
+	}
+
+	attributes := otelAttributeMap(record["attributes"])
+	expected := map[string]map[string]interface{}{
+		"http.request.method":       {"stringValue": "POST"},
+		"http.response.status_code": {"intValue": "404"},
+		"url.path":                  {"stringValue": "/items"},
+		"url.query":                 {"stringValue": "page=2&token=REDACTED"},
+		"server.address":            {"stringValue": "www.foo.com"},
+		"server.port":               {"intValue": "8080"},
+		"client.address":            {"stringValue": "192.0.2.1"},
+		"user_agent.original":       {"stringValue": "curl/7.30.0"},
+		"network.protocol.version":  {"stringValue": "1.1"},
+		"http.request.body.size":    {"intValue": "4"},
+		"http.response.body.size":   {"intValue": "7"},
+		"tenant":                    {"stringValue": "acme"},
+	}
+	for key, value := range expected {
+		for kind, v := range value {
+			if attributes[key][kind] != v {
+				t.Error("Attribute", key, "not set correctly, expected", value, "got", attributes[key])
+			}
+		}
+	}
+
+	if _, ok := attributes["http.server.request.duration"]["doubleValue"]; !ok {
+		t.Error("Attribute http.server.request.duration not set, got", attributes["http.server.request.duration"])
+	}
+
+	values := attributes["http.request.header.user-agent"]["arrayValue"].(map[string]interface{})["values"].([]interface{})
+	if values[0].(map[string]interface{})["stringValue"] != "curl/7.30.0" {
+		t.Error("Header not set as a string array, got", values)
+	}
+}
+
+func TestOTelFormat_levels(t *testing.T) {
+	formatter, _ := NewOTelFormatter("fooService")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	res, req := createRequestAndResponse()
+
+	logger.Debug(res, req)
+	logger.Info(res, req)
+	logger.Warning(res, req)
+	logger.Error(res, req)
+	logger.Critical(res, req)
+
+	expected := []float64{5, 9, 13, 17, 21}
+	i := 0
+	for node := memBackend.Head(); node != nil; node = node.Next() {
+		_, record := otelRecord(t, node.Record.Message())
+		if record["severityNumber"] != expected[i] {
+			t.Error("Severity not mapped correctly, expected", expected[i], "got", record["severityNumber"])
+		}
+		i++
+	}
+}
+
+func TestOTelFormat_otherFields(t *testing.T) {
+	formatter, _ := NewOTelFormatter("fooService")
+	formatter.ScopeName = "custom"
+
+	data, err := formatter.Format(&testingFields{"hi", "there", 101, []int{1, 2}})
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	if !strings.Contains(data, `"scope":{"name":"custom"}`) {
+		t.Error("Scope name not set, got", data)
+	}
+
+	_, record := otelRecord(t, data)
+	attributes := otelAttributeMap(record["attributes"])
+	if attributes["Hot"]["stringValue"] != "hi" || attributes["Block"]["doubleValue"] != 101.0 {
+		t.Error("Fields not set as attributes, got", attributes)
+	}
+}
@@ -0,0 +1,119 @@
+package httpclerk
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultRedactedQueryParams are redacted by the QueryPolicy NewHTTPLogger
+// sets up.
+var DefaultRedactedQueryParams = []string{
+	"token",
+	"access_token",
+	"refresh_token",
+	"api_key",
+	"password",
+	"secret",
+	"signature",
+}
+
+// QueryPolicy decides how the query string is logged, and hides the values
+// of sensitive parameters. Parameter names are matched case-insensitively.
+type QueryPolicy struct {
+	// Parameters whose values are replaced by Mask, so ?token=abc&page=2 is
+	// logged as ?token=REDACTED&page=2
+	Redact []string
+	// Replaces the values of redacted parameters. Defaults to "REDACTED".
+	Mask string
+	// When true, the query is logged as a "query" object of parameter
+	// values instead of as part of the path, so each parameter can be
+	// filtered on.
+	Structured bool
+}
+
+// NewQueryPolicy constructor, redacting DefaultRedactedQueryParams.
+func NewQueryPolicy() (*QueryPolicy, error) {
+	redact := make([]string, len(DefaultRedactedQueryParams))
+	copy(redact, DefaultRedactedQueryParams)
+	return &QueryPolicy{Redact: redact}, nil
+}
+
+// Sets the path and query fields from the request URI.
+func (policy *QueryPolicy) apply(f *fields, requestURI string) {
+	i := strings.IndexByte(requestURI, '?')
+	if i < 0 {
+		f.Path = requestURI
+		return
+	}
+	path, rawQuery := requestURI[:i], requestURI[i+1:]
+
+	if !policy.Structured {
+		f.Path = path + "?" + policy.redactRaw(rawQuery)
+		return
+	}
+
+	f.Path = path
+	query, _ := url.ParseQuery(rawQuery) // Keeps what it can parse
+	for name, values := range query {
+		if containsFold(policy.Redact, name) {
+			for i := range values {
+				values[i] = policy.mask()
+			}
+		}
+	}
+	f.Query = query
+}
+
+// Redacts the query of a URL, e.g. a Referer.
+func (policy *QueryPolicy) redactURL(rawURL string) string {
+	i := strings.IndexByte(rawURL, '?')
+	if i < 0 {
+		return rawURL
+	}
+	return rawURL[:i+1] + policy.redactRaw(rawURL[i+1:])
+}
+
+// Redacts the Referer headers' queries. The map and changed values are
+// copies, so the request's own headers are left alone.
+func (policy *QueryPolicy) redactReferer(headers map[string][]string) map[string][]string {
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if strings.EqualFold(name, "Referer") {
+			copied := make([]string, len(values))
+			for i, value := range values {
+				copied[i] = policy.redactURL(value)
+			}
+			values = copied
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// Redacts values in place, so everything else is logged exactly as sent.
+func (policy *QueryPolicy) redactRaw(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		rawName := param
+		if j := strings.IndexByte(param, '='); j >= 0 {
+			rawName = param[:j]
+		}
+
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+
+		if containsFold(policy.Redact, name) {
+			params[i] = rawName + "=" + url.QueryEscape(policy.mask())
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func (policy *QueryPolicy) mask() string {
+	if policy.Mask == "" {
+		return "REDACTED"
+	}
+	return policy.Mask
+}
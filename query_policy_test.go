@@ -0,0 +1,125 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loggedQueryFields(configure func(*HTTPLogger), uri string) map[string]interface{} {
+	memBackend, logger := loadLogger()
+	configure(logger)
+	req, _ := http.NewRequest("GET", uri, nil)
+
+	logger.Info(httptest.NewRecorder(), req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	return m["@fields"].(map[string]interface{})
+}
+
+func TestQueryPolicy_defaultRedaction(t *testing.T) {
+	fields := loggedQueryFields(func(logger *HTTPLogger) {}, "http://www.foo.com/items?token=abc&page=2&Password=hunter2")
+
+	if fields["path"] != "/items?token=REDACTED&page=2&Password=REDACTED" {
+		t.Error("@fields['path'] not redacted, expected /items?token=REDACTED&page=2&Password=REDACTED, got", fields["path"])
+	}
+
+	if _, ok := fields["query"]; ok {
+		t.Error("@fields['query'] set without a structured policy, got", fields["query"])
+	}
+}
+
+func TestQueryPolicy_customRedaction(t *testing.T) {
+	fields := loggedQueryFields(func(logger *HTTPLogger) {
+		logger.QueryPolicy.Redact = []string{"email"}
+		logger.QueryPolicy.Mask = "[hidden]"
+	}, "http://www.foo.com/search?e%6Dail=me%40example.com&q=a+b&token=abc")
+
+	if fields["path"] != "/search?e%6Dail=%5Bhidden%5D&q=a+b&token=abc" {
+		t.Error("@fields['path'] not redacted, expected /search?e%6Dail=%5Bhidden%5D&q=a+b&token=abc, got", fields["path"])
+	}
+}
+
+func TestQueryPolicy_structured(t *testing.T) {
+	fields := loggedQueryFields(func(logger *HTTPLogger) {
+		logger.QueryPolicy.Structured = true
+	}, "http://www.foo.com/items?token=abc&page=2&tag=a&tag=b")
+
+	if fields["path"] != "/items" {
+		t.Error("@fields['path'] not set correctly, expected /items, got", fields["path"])
+	}
+
+	expected := map[string]interface{}{
+		"token": []interface{}{"REDACTED"},
+		"page":  []interface{}{"2"},
+		"tag":   []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(fields["query"], expected) {
+		t.Error("@fields['query'] not set correctly, expected", expected, "got", fields["query"])
+	}
+}
+
+func TestQueryPolicy_structuredText(t *testing.T) {
+	formatter, _ := NewTextFormatter("testApp")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	logger.QueryPolicy.Structured = true
+	req, _ := http.NewRequest("GET", "http://www.foo.com/items?token=abc&page=2", nil)
+
+	logger.Info(httptest.NewRecorder(), req)
+	lastWrite := memBackend.Head().Record.Message()
+
+	if !strings.Contains(lastWrite, "Path: /items ") {
+		t.Error("Path not logged without the query, got", lastWrite)
+	}
+
+	if !strings.Contains(lastWrite, "Query: map[page:[2] token:[REDACTED]]") {
+		t.Error("Query not logged, got", lastWrite)
+	}
+}
+
+func TestQueryPolicy_disabled(t *testing.T) {
+	fields := loggedQueryFields(func(logger *HTTPLogger) {
+		logger.QueryPolicy = nil
+	}, "http://www.foo.com/items?token=abc")
+
+	if fields["path"] != "/items?token=abc" {
+		t.Error("@fields['path'] not logged as is, expected /items?token=abc, got", fields["path"])
+	}
+}
+
+func TestQueryPolicy_referer(t *testing.T) {
+	formatter, _ := NewCombinedLogFormatter()
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req := httptest.NewRequest("GET", "http://www.foo.com/", nil)
+	req.Header.Set("Referer", "https://x/reset?token=SECRET&page=2")
+
+	logger.Info(httptest.NewRecorder(), req)
+
+	line := memBackend.Head().Record.Message()
+	if !strings.Contains(line, `"https://x/reset?token=REDACTED&page=2"`) {
+		t.Error("Referer query not redacted, got", line)
+	}
+
+	if req.Header.Get("Referer") != "https://x/reset?token=SECRET&page=2" {
+		t.Error("Request's Referer header changed, got", req.Header.Get("Referer"))
+	}
+
+	memBackend, logger = loadLogger()
+	logger.Info(httptest.NewRecorder(), req)
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	headers := m["@fields"].(map[string]interface{})["headers"].(map[string]interface{})
+	if referer := headers["Referer"].([]interface{})[0]; referer != "https://x/reset?token=REDACTED&page=2" {
+		t.Error("Referer header query not redacted in LogStash, got", referer)
+	}
+
+	logfmt, _ := NewLogfmtFormatter("fooApp")
+	memBackend, logger = loadLoggerWithFormatter(logfmt)
+	logger.Info(httptest.NewRecorder(), req)
+
+	if line := memBackend.Head().Record.Message(); strings.Contains(line, "SECRET") || strings.Count(line, "token=REDACTED") != 2 {
+		t.Error("Referer header query not redacted in logfmt, got", line)
+	}
+}
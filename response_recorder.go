@@ -0,0 +1,233 @@
+package httpclerk
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseRecorder wraps an http.ResponseWriter and records the status code
+// and number of bytes written through it, so that HTTPLogger can log them.
+// Middleware uses one for every request; use it directly if you call the
+// level methods yourself.
+//
+// Hand handlers Writer rather than the recorder itself, so they can still
+// flush, hijack and so on when the underlying writer supports it.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status       int
+	wroteHeader  bool
+	hijacked     bool
+	bytesWritten int64
+}
+
+// NewResponseRecorder constructor
+func NewResponseRecorder(res http.ResponseWriter) (*ResponseRecorder, error) {
+	return &ResponseRecorder{ResponseWriter: res, status: http.StatusOK}, nil
+}
+
+// Status returns the status code sent to the client. It is http.StatusOK
+// until the handler writes something else, as that is what net/http sends
+// for a handler that never calls WriteHeader. A connection hijacked before
+// any status was written, e.g. for a websocket upgrade, is reported as
+// http.StatusSwitchingProtocols, as net/http no longer sends one.
+func (rec *ResponseRecorder) Status() int {
+	return rec.status
+}
+
+// Hijacked reports whether the handler took over the connection. Bytes the
+// handler wrote to it afterwards aren't counted in BytesWritten.
+func (rec *ResponseRecorder) Hijacked() bool {
+	return rec.hijacked
+}
+
+// BytesWritten returns the number of response body bytes written so far.
+func (rec *ResponseRecorder) BytesWritten() int64 {
+	return rec.bytesWritten
+}
+
+func (rec *ResponseRecorder) WriteHeader(code int) {
+	// Informational responses may be sent any number of times before the
+	// final status, so they don't count as writing the header. Like
+	// net/http, 101 Switching Protocols is taken as final.
+	informational := code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols
+	if !rec.wroteHeader && !informational {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *ResponseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytesWritten += int64(n)
+	return n, err
+}
+
+// ReadFrom lets io.Copy use the underlying writer's io.ReaderFrom (and so
+// sendfile for *os.File sources) when it has one.
+func (rec *ResponseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	readerFrom, ok := rec.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(writerOnly{rec}, src)
+	}
+	rec.wroteHeader = true
+	n, err := readerFrom.ReadFrom(src)
+	rec.bytesWritten += n
+	return n, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (rec *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Hides ReadFrom so io.Copy doesn't call back into ResponseRecorder.ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
+
+// Writer returns the recorder as an http.ResponseWriter that implements
+// http.Flusher, http.Hijacker, http.Pusher and http.CloseNotifier only where
+// the underlying writer does, so handlers can detect them as usual.
+func (rec *ResponseRecorder) Writer() http.ResponseWriter {
+	_, isFlusher := rec.ResponseWriter.(http.Flusher)
+	_, isHijacker := rec.ResponseWriter.(http.Hijacker)
+	_, isPusher := rec.ResponseWriter.(http.Pusher)
+	_, isCloseNotifier := rec.ResponseWriter.(http.CloseNotifier)
+
+	f, h, p, c := recFlusher{rec}, recHijacker{rec}, recPusher{rec}, recCloseNotifier{rec}
+
+	switch {
+	case isFlusher && isHijacker && isPusher && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recHijacker
+			recPusher
+			recCloseNotifier
+		}{rec, f, h, p, c}
+	case isFlusher && isHijacker && isPusher:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recHijacker
+			recPusher
+		}{rec, f, h, p}
+	case isFlusher && isHijacker && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recHijacker
+			recCloseNotifier
+		}{rec, f, h, c}
+	case isFlusher && isPusher && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recPusher
+			recCloseNotifier
+		}{rec, f, p, c}
+	case isHijacker && isPusher && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recHijacker
+			recPusher
+			recCloseNotifier
+		}{rec, h, p, c}
+	case isFlusher && isHijacker:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recHijacker
+		}{rec, f, h}
+	case isFlusher && isPusher:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recPusher
+		}{rec, f, p}
+	case isFlusher && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+			recCloseNotifier
+		}{rec, f, c}
+	case isHijacker && isPusher:
+		return struct {
+			*ResponseRecorder
+			recHijacker
+			recPusher
+		}{rec, h, p}
+	case isHijacker && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recHijacker
+			recCloseNotifier
+		}{rec, h, c}
+	case isPusher && isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recPusher
+			recCloseNotifier
+		}{rec, p, c}
+	case isFlusher:
+		return struct {
+			*ResponseRecorder
+			recFlusher
+		}{rec, f}
+	case isHijacker:
+		return struct {
+			*ResponseRecorder
+			recHijacker
+		}{rec, h}
+	case isPusher:
+		return struct {
+			*ResponseRecorder
+			recPusher
+		}{rec, p}
+	case isCloseNotifier:
+		return struct {
+			*ResponseRecorder
+			recCloseNotifier
+		}{rec, c}
+	}
+	return rec
+}
+
+// The optional interfaces, each only mixed into Writer's result when the
+// underlying writer has it.
+type recFlusher struct{ rec *ResponseRecorder }
+
+func (f recFlusher) Flush() {
+	f.rec.wroteHeader = true
+	f.rec.ResponseWriter.(http.Flusher).Flush()
+}
+
+type recHijacker struct{ rec *ResponseRecorder }
+
+func (h recHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.rec.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.rec.hijacked = true
+		if !h.rec.wroteHeader {
+			h.rec.status = http.StatusSwitchingProtocols
+			h.rec.wroteHeader = true
+		}
+	}
+	return conn, rw, err
+}
+
+type recPusher struct{ rec *ResponseRecorder }
+
+func (p recPusher) Push(target string, opts *http.PushOptions) error {
+	return p.rec.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type recCloseNotifier struct{ rec *ResponseRecorder }
+
+func (c recCloseNotifier) CloseNotify() <-chan bool {
+	return c.rec.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
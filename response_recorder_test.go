@@ -0,0 +1,291 @@
+package httpclerk
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseRecorder_status(t *testing.T) {
+	rec, _ := NewResponseRecorder(httptest.NewRecorder())
+
+	if rec.Status() != http.StatusOK {
+		t.Error("Status not defaulted correctly, expected 200, got", rec.Status())
+	}
+
+	rec.WriteHeader(http.StatusContinue)
+	rec.WriteHeader(http.StatusAccepted)
+	rec.WriteHeader(http.StatusInternalServerError)
+
+	if rec.Status() != http.StatusAccepted {
+		t.Error("Status not recorded correctly, expected 202, got", rec.Status())
+	}
+}
+
+func TestResponseRecorder_switchingProtocols(t *testing.T) {
+	rec, _ := NewResponseRecorder(httptest.NewRecorder())
+
+	rec.WriteHeader(http.StatusSwitchingProtocols)
+	rec.Write([]byte("upgraded"))
+	rec.WriteHeader(http.StatusOK)
+
+	if rec.Status() != http.StatusSwitchingProtocols {
+		t.Error("Status not recorded correctly, expected 101, got", rec.Status())
+	}
+}
+
+func TestResponseRecorder_implicitStatusOnWrite(t *testing.T) {
+	rec, _ := NewResponseRecorder(httptest.NewRecorder())
+
+	rec.Write([]byte("hello"))
+	rec.WriteHeader(http.StatusNotFound)
+
+	if rec.Status() != http.StatusOK {
+		t.Error("Status not recorded correctly, expected 200, got", rec.Status())
+	}
+}
+
+func TestResponseRecorder_bytesWritten(t *testing.T) {
+	rec, _ := NewResponseRecorder(httptest.NewRecorder())
+
+	rec.Write([]byte("hello"))
+	rec.ReadFrom(strings.NewReader(" world"))
+
+	if rec.BytesWritten() != 11 {
+		t.Error("Bytes not recorded correctly, expected 11, got", rec.BytesWritten())
+	}
+
+	if written := fetchBytesWritten(rec); written != 11 {
+		t.Error("Bytes not fetched correctly, expected 11, got", written)
+	}
+}
+
+func TestResponseRecorder_fetchStatusCode(t *testing.T) {
+	rec, _ := NewResponseRecorder(httptest.NewRecorder())
+	rec.WriteHeader(http.StatusTeapot)
+
+	if status := fetchStatusCode(rec); status != "418" {
+		t.Error("Status not fetched correctly, expected 418, got", status)
+	}
+}
+
+func TestResponseRecorder_flusher(t *testing.T) {
+	underlying := httptest.NewRecorder()
+	rec, _ := NewResponseRecorder(underlying)
+
+	flusher, ok := rec.Writer().(http.Flusher)
+	if !ok {
+		t.Fatal("Writer does not implement http.Flusher")
+	}
+
+	flusher.Flush()
+	if !underlying.Flushed {
+		t.Error("Flush not passed through to the underlying writer")
+	}
+}
+
+func TestResponseRecorder_hijacker(t *testing.T) {
+	underlying := &hijackableWriter{ResponseRecorder: httptest.NewRecorder()}
+	rec, _ := NewResponseRecorder(underlying)
+
+	hijacker, ok := rec.Writer().(http.Hijacker)
+	if !ok {
+		t.Fatal("Writer does not implement http.Hijacker")
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		t.Error("Error hijacking connection", err)
+	}
+	if conn != underlying.conn {
+		t.Error("Hijack not passed through to the underlying writer")
+	}
+	if !rec.Hijacked() || rec.Status() != http.StatusSwitchingProtocols {
+		t.Error("Hijack not recorded, got status", rec.Status())
+	}
+	conn.Close()
+}
+
+func TestResponseRecorder_hijackedStatus(t *testing.T) {
+	memBackend, logger := loadLogger()
+	logged := make(chan struct{})
+
+	upgrade := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error("Error hijacking connection", err)
+			return
+		}
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+		conn.Close()
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrade.ServeHTTP(w, r)
+		close(logged)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Error upgrading", err)
+	}
+	res.Body.Close()
+	<-logged
+
+	m, _ := decodeJSONToMap(memBackend.Head().Record.Message())
+	fields := m["@fields"].(map[string]interface{})
+	if fields["status"] != "101" {
+		t.Error("Hijacked connection not logged as 101, got", fields["status"])
+	}
+}
+
+func TestResponseRecorder_pusher(t *testing.T) {
+	underlying := &pushableWriter{ResponseRecorder: httptest.NewRecorder()}
+	rec, _ := NewResponseRecorder(underlying)
+
+	pusher, ok := rec.Writer().(http.Pusher)
+	if !ok {
+		t.Fatal("Writer does not implement http.Pusher")
+	}
+
+	pusher.Push("/app.css", nil)
+	if underlying.pushed != "/app.css" {
+		t.Error("Push not passed through to the underlying writer, got", underlying.pushed)
+	}
+}
+
+func TestResponseRecorder_unsupportedInterfaces(t *testing.T) {
+	rec, _ := NewResponseRecorder(plainWriter{httptest.NewRecorder()})
+	res := rec.Writer()
+
+	if _, ok := res.(http.Flusher); ok {
+		t.Error("Writer implements http.Flusher, though the underlying writer doesn't")
+	}
+	if _, ok := res.(http.Hijacker); ok {
+		t.Error("Writer implements http.Hijacker, though the underlying writer doesn't")
+	}
+	if _, ok := res.(http.Pusher); ok {
+		t.Error("Writer implements http.Pusher, though the underlying writer doesn't")
+	}
+	if _, ok := res.(http.CloseNotifier); ok {
+		t.Error("Writer implements http.CloseNotifier, though the underlying writer doesn't")
+	}
+
+	if err := http.NewResponseController(res).Flush(); !errors.Is(err, http.ErrNotSupported) {
+		t.Error("Expected http.ErrNotSupported flushing, got", err)
+	}
+
+	res.WriteHeader(http.StatusAccepted)
+	if rec.Status() != http.StatusAccepted {
+		t.Error("Status not recorded through Writer, got", rec.Status())
+	}
+}
+
+func TestResponseRecorder_someInterfaces(t *testing.T) {
+	underlying := &pushableWriter{ResponseRecorder: httptest.NewRecorder()}
+	rec, _ := NewResponseRecorder(underlying)
+	res := rec.Writer()
+
+	if _, ok := res.(http.Flusher); !ok {
+		t.Error("Writer does not implement http.Flusher")
+	}
+	if _, ok := res.(http.Pusher); !ok {
+		t.Error("Writer does not implement http.Pusher")
+	}
+	if _, ok := res.(http.Hijacker); ok {
+		t.Error("Writer implements http.Hijacker, though the underlying writer doesn't")
+	}
+	if status, ok := res.(interface{ Status() int }); !ok || status.Status() != http.StatusOK {
+		t.Error("Writer does not expose the recorded status")
+	}
+}
+
+func TestResponseRecorder_readerFrom(t *testing.T) {
+	underlying := httptest.NewRecorder()
+	rec, _ := NewResponseRecorder(underlying)
+
+	n, err := rec.ReadFrom(strings.NewReader("streamed body"))
+	if err != nil {
+		t.Error("Error reading from source", err)
+	}
+	if n != 13 || underlying.Body.String() != "streamed body" {
+		t.Error("Body not copied correctly, got", underlying.Body.String())
+	}
+	if rec.Status() != http.StatusOK {
+		t.Error("Status not recorded correctly, expected 200, got", rec.Status())
+	}
+}
+
+func TestResponseRecorder_closeNotifier(t *testing.T) {
+	underlying := &closeNotifyingWriter{httptest.NewRecorder(), make(chan bool, 1)}
+	rec, _ := NewResponseRecorder(underlying)
+
+	notifier, ok := rec.Writer().(http.CloseNotifier)
+	if !ok {
+		t.Fatal("Writer does not implement http.CloseNotifier")
+	}
+
+	underlying.closed <- true
+	select {
+	case <-notifier.CloseNotify():
+	default:
+		t.Error("CloseNotify not passed through to the underlying writer")
+	}
+}
+
+func TestResponseRecorder_unwrap(t *testing.T) {
+	underlying := httptest.NewRecorder()
+	rec, _ := NewResponseRecorder(underlying)
+
+	if rec.Unwrap() != underlying {
+		t.Error("Unwrap did not return the underlying writer")
+	}
+}
+
+// *************************************
+// Helper types
+// *************************************
+
+// Hides the optional interfaces of httptest.ResponseRecorder, like Flush.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+type hijackableWriter struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (w *hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	client.Close()
+	w.conn = server
+	return server, nil, nil
+}
+
+type pushableWriter struct {
+	*httptest.ResponseRecorder
+	pushed string
+}
+
+func (w *pushableWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+type closeNotifyingWriter struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (w *closeNotifyingWriter) CloseNotify() <-chan bool {
+	return w.closed
+}
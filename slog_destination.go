@@ -0,0 +1,96 @@
+package httpclerk
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelSlogCritical is the slog level Critical records are logged at, above
+// slog.LevelError. Handlers print it as ERROR+4 unless their ReplaceAttr is
+// ReplaceSlogLevel.
+const LevelSlogCritical = slog.LevelError + 4
+
+// SlogDestination logs records to a log/slog Logger.
+//
+// With NewHTTPLogger, it logs the formatted record as the message. With
+// NewStructuredHTTPLogger, the message is "METHOD PATH STATUS" and the fields
+// are passed as attributes in an "http" group, so the slog handler picks the
+// encoding.
+type SlogDestination struct {
+	logger *slog.Logger
+}
+
+// NewSlogDestination constructor.
+func NewSlogDestination(logger *slog.Logger) (*SlogDestination, error) {
+	return &SlogDestination{logger: logger}, nil
+}
+
+// ReplaceSlogLevel names LevelSlogCritical CRITICAL. Use it as the
+// ReplaceAttr of slog.HandlerOptions.
+func ReplaceSlogLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level == LevelSlogCritical {
+			a.Value = slog.StringValue("CRITICAL")
+		}
+	}
+	return a
+}
+
+var slogLevels = map[Level]slog.Level{
+	LevelDebug:    slog.LevelDebug,
+	LevelInfo:     slog.LevelInfo,
+	LevelWarning:  slog.LevelWarn,
+	LevelError:    slog.LevelError,
+	LevelCritical: LevelSlogCritical,
+}
+
+func (dest *SlogDestination) Debug(data string, args ...interface{}) {
+	dest.logger.Log(context.Background(), slog.LevelDebug, sprintf(data, args))
+}
+
+func (dest *SlogDestination) Info(data string, args ...interface{}) {
+	dest.logger.Log(context.Background(), slog.LevelInfo, sprintf(data, args))
+}
+
+func (dest *SlogDestination) Warning(data string, args ...interface{}) {
+	dest.logger.Log(context.Background(), slog.LevelWarn, sprintf(data, args))
+}
+
+func (dest *SlogDestination) Error(data string, args ...interface{}) {
+	dest.logger.Log(context.Background(), slog.LevelError, sprintf(data, args))
+}
+
+func (dest *SlogDestination) Critical(data string, args ...interface{}) {
+	dest.logger.Log(context.Background(), LevelSlogCritical, sprintf(data, args))
+}
+
+func (dest *SlogDestination) LogRecord(record Record) {
+	handler := dest.logger.Handler()
+	level := slogLevels[record.Level]
+	if !handler.Enabled(context.Background(), level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, len(record.Attrs))
+	for _, attr := range record.Attrs {
+		attrs = append(attrs, slogAttr(attr))
+	}
+
+	r := slog.NewRecord(record.Time, level, record.Message, 0)
+	r.AddAttrs(slog.Attr{Key: "http", Value: slog.GroupValue(attrs...)})
+	handler.Handle(context.Background(), r)
+}
+
+// Groups, like headers and timings, become slog groups.
+func slogAttr(attr Attr) slog.Attr {
+	group, ok := attr.Value.([]Attr)
+	if !ok {
+		return slog.Any(attr.Key, attr.Value)
+	}
+
+	attrs := make([]slog.Attr, 0, len(group))
+	for _, a := range group {
+		attrs = append(attrs, slogAttr(a))
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(attrs...)}
+}
@@ -0,0 +1,142 @@
+package httpclerk
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadSlogLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: ReplaceSlogLevel}))
+}
+
+func decodeSlogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal("Error decoding slog record", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestSlogDestination(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewSlogDestination(loadSlogLogger(&buf))
+
+	dest.Debug("%s", "debug 100%")
+	dest.Info("%s", "info")
+	dest.Warning("%s", "warning")
+	dest.Error("%s", "error")
+	dest.Critical("%s", "critical")
+
+	records := decodeSlogLines(t, &buf)
+	expected := [][]string{
+		{"DEBUG", "debug 100%"},
+		{"INFO", "info"},
+		{"WARN", "warning"},
+		{"ERROR", "error"},
+		{"CRITICAL", "critical"},
+	}
+	for i, record := range records {
+		if record["level"] != expected[i][0] || record["msg"] != expected[i][1] {
+			t.Error("Record not logged correctly, expected", expected[i], "got", record)
+		}
+	}
+}
+
+func TestSlogDestination_criticalLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelSlogCritical}))
+	dest, _ := NewSlogDestination(logger)
+
+	dest.Error("%s", "dropped")
+	dest.Critical("%s", "kept")
+
+	if !strings.Contains(buf.String(), "level=ERROR+4 msg=kept") || strings.Contains(buf.String(), "dropped") {
+		t.Error("Critical not logged above Error, got", buf.String())
+	}
+}
+
+func TestSlogDestination_formatted(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewSlogDestination(loadSlogLogger(&buf))
+	formatter, _ := NewTextFormatter("foo")
+	logger, _ := NewHTTPLogger("foo", dest, formatter)
+	res, req := createRequestAndResponse()
+
+	logger.Warning(res, req)
+
+	record := decodeSlogLines(t, &buf)[0]
+	if record["level"] != "WARN" || !strings.Contains(record["msg"].(string), "Method: PUT Path: /1234.json ") {
+		t.Error("Formatted record not logged as the message, got", record)
+	}
+}
+
+func TestSlogDestination_structured(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewSlogDestination(loadSlogLogger(&buf))
+	logger, _ := NewStructuredHTTPLogger("foo", dest)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	req := httptest.NewRequest("GET", "http://www.foo.com/items?token=abc", nil)
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddTiming(r.Context(), "db", 2*time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := decodeSlogLines(t, &buf)[0]
+	if record["level"] != "ERROR" || record["msg"] != "GET /items?token=REDACTED 503" {
+		t.Error("Record not logged correctly, got", record)
+	}
+
+	fields, ok := record["http"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Fields not logged in an http group, got", record)
+	}
+
+	expected := map[string]interface{}{
+		"method":      "GET",
+		"status":      "503",
+		"path":        "/items?token=REDACTED",
+		"host":        "www.foo.com",
+		"tenant":      "acme",
+		"remote_addr": "192.0.2.1:1234",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Error("Field", key, "not logged correctly, expected", value, "got", fields[key])
+		}
+	}
+
+	headers := fields["headers"].(map[string]interface{})
+	if headers["User-Agent"].([]interface{})[0] != "curl/7.30.0" {
+		t.Error("Headers not logged as a group, got", headers)
+	}
+	if fields["timings"].(map[string]interface{})["db"] != 2.0 {
+		t.Error("Timings not logged as a group, got", fields["timings"])
+	}
+}
+
+func TestSlogDestination_structuredOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	dest, _ := NewSlogDestination(logger)
+	httpLogger, _ := NewStructuredHTTPLogger("foo", dest)
+	res, req := createRequestAndResponse()
+
+	httpLogger.Info(res, req)
+
+	if !strings.Contains(buf.String(), `msg="PUT /1234.json" http.method=PUT http.status="" http.path=/1234.json http.host=www.foo.com http.headers.X-Foo-Header=[Bar] `) {
+		t.Error("Attributes not logged in order, got", buf.String())
+	}
+}
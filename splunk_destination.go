@@ -0,0 +1,283 @@
+package httpclerk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Default number of events SplunkDestination holds before dropping them.
+	DefaultSplunkQueueSize = 10000
+	// Default number of events SplunkDestination sends in one request.
+	DefaultSplunkBatchSize = 100
+	// Default time SplunkDestination allows one request to HEC.
+	DefaultSplunkTimeout = 10 * time.Second
+	// Default time SplunkDestination.Close waits for queued events to be sent.
+	DefaultSplunkCloseTimeout = 30 * time.Second
+)
+
+var splunkClient = &http.Client{Timeout: DefaultSplunkTimeout}
+
+// SplunkDestination sends events, such as those from a SplunkFormatter, to a
+// Splunk HTTP Event Collector, e.g. https://splunk:8088/services/collector/event.
+//
+// Events are queued and sent in batches from a separate goroutine, so
+// logging never waits on Splunk. When the queue is full, events are dropped.
+// Failed batches are retried with exponential backoff. Records that aren't
+// HEC events are sent as the event of one. Call Close to send what's queued
+// before exiting.
+type SplunkDestination struct {
+	url   string
+	token string
+
+	// Defaults to DefaultSplunkBatchSize.
+	BatchSize int
+	// How long an event waits for a batch to fill. Defaults to a second.
+	FlushInterval time.Duration
+	// Attempts after the first to send a batch. Defaults to 3; negative disables retries.
+	MaxRetries int
+	// Wait before the first retry, doubled for each one after. Defaults to 500ms.
+	RetryBackoff time.Duration
+	// Defaults to a client that gives up on a request after DefaultSplunkTimeout.
+	Client *http.Client
+	// How long Close waits for queued events. Defaults to DefaultSplunkCloseTimeout.
+	CloseTimeout time.Duration
+	// Called, if set, with every error sending a batch or queueing an event.
+	OnError func(error)
+
+	queue  chan string
+	start  sync.Once
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewSplunkDestination constructor. queueSize may be 0 to use DefaultSplunkQueueSize.
+func NewSplunkDestination(url, token string, queueSize int) (*SplunkDestination, error) {
+	if url == "" {
+		return nil, errors.New("Splunk destination needs an HEC URL")
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultSplunkQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SplunkDestination{
+		url:           url,
+		token:         token,
+		BatchSize:     DefaultSplunkBatchSize,
+		FlushInterval: time.Second,
+		MaxRetries:    3,
+		RetryBackoff:  500 * time.Millisecond,
+		CloseTimeout:  DefaultSplunkCloseTimeout,
+		queue:         make(chan string, queueSize),
+		done:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+func (dest *SplunkDestination) Debug(data string, args ...interface{}) {
+	dest.enqueue(sprintf(data, args))
+}
+
+func (dest *SplunkDestination) Info(data string, args ...interface{}) {
+	dest.enqueue(sprintf(data, args))
+}
+
+func (dest *SplunkDestination) Warning(data string, args ...interface{}) {
+	dest.enqueue(sprintf(data, args))
+}
+
+func (dest *SplunkDestination) Error(data string, args ...interface{}) {
+	dest.enqueue(sprintf(data, args))
+}
+
+func (dest *SplunkDestination) Critical(data string, args ...interface{}) {
+	dest.enqueue(sprintf(data, args))
+}
+
+// Close sends the queued events, waiting up to CloseTimeout for them to be
+// sent or dropped. When that runs out, the request in flight is abandoned,
+// what's still queued is dropped and Close returns an error. Events logged
+// after Close are dropped.
+func (dest *SplunkDestination) Close() error {
+	dest.mu.Lock()
+	if dest.closed {
+		dest.mu.Unlock()
+		return nil
+	}
+	dest.closed = true
+	close(dest.queue)
+	dest.mu.Unlock()
+
+	dest.start.Do(func() { go dest.run() })
+
+	timeout := dest.CloseTimeout
+	if timeout <= 0 {
+		timeout = DefaultSplunkCloseTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-dest.done:
+		dest.cancel()
+		return nil
+	case <-timer.C:
+		dest.cancel()
+		return errors.New(fmt.Sprintf("Splunk destination not drained after %s, queued events dropped", timeout))
+	}
+}
+
+func (dest *SplunkDestination) enqueue(event string) {
+	dest.mu.RLock()
+	defer dest.mu.RUnlock()
+
+	if dest.closed {
+		dest.reportError(errors.New("Splunk destination is closed, event dropped"))
+		return
+	}
+
+	dest.start.Do(func() { go dest.run() })
+
+	select {
+	case dest.queue <- event:
+	default:
+		dest.reportError(errors.New(fmt.Sprintf("Splunk queue is full at %d events, event dropped", cap(dest.queue))))
+	}
+}
+
+func (dest *SplunkDestination) run() {
+	defer close(dest.done)
+
+	batchSize := dest.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultSplunkBatchSize
+	}
+	interval := dest.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch bytes.Buffer
+	count := 0
+	flush := func() {
+		if count > 0 {
+			err := dest.post(batch.Bytes())
+			if dest.ctx.Err() == nil {
+				dest.reportError(err)
+			}
+			batch.Reset()
+			count = 0
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-dest.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch.Write(splunkEnvelope(event))
+			batch.WriteByte('\n')
+			count++
+			if count >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// HEC only accepts objects with an event, so anything else is wrapped in one.
+func splunkEnvelope(event string) []byte {
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal([]byte(event), &envelope) == nil && envelope["event"] != nil {
+		return []byte(event)
+	}
+
+	var raw interface{} = event
+	var decoded interface{}
+	if json.Unmarshal([]byte(event), &decoded) == nil {
+		raw = json.RawMessage(event)
+	}
+	data, _ := json.Marshal(map[string]interface{}{"event": raw})
+	return data
+}
+
+func (dest *SplunkDestination) post(body []byte) error {
+	backoff := dest.RetryBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = dest.send(body)
+		if err == nil || !retry || attempt >= dest.MaxRetries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-dest.ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// Network errors, 429s and 5xxs are worth retrying. Other errors aren't.
+func (dest *SplunkDestination) send(body []byte) (bool, error) {
+	if err := dest.ctx.Err(); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("POST", dest.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(dest.ctx)
+	req.Header.Set("Authorization", "Splunk "+dest.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := dest.Client
+	if client == nil {
+		client = splunkClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, res.Body)
+		return false, nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	err = errors.New(fmt.Sprintf("Splunk HEC returned %d: %s", res.StatusCode, bytes.TrimSpace(message)))
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500, err
+}
+
+func (dest *SplunkDestination) reportError(err error) {
+	if err != nil && dest.OnError != nil {
+		dest.OnError(err)
+	}
+}
@@ -0,0 +1,251 @@
+package httpclerk
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A stand-in HEC that records each batch and fails the first failures requests.
+type fakeHEC struct {
+	mu       sync.Mutex
+	batches  [][]string
+	auth     []string
+	failures int
+	status   int
+	block    chan struct{}
+}
+
+func (hec *fakeHEC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if hec.block != nil {
+		<-hec.block
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	hec.mu.Lock()
+	defer hec.mu.Unlock()
+
+	hec.auth = append(hec.auth, r.Header.Get("Authorization"))
+	if hec.failures > 0 {
+		hec.failures--
+		w.WriteHeader(hec.status)
+		w.Write([]byte(`{"text":"Server is busy","code":9}`))
+		return
+	}
+
+	var events []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		events = append(events, scanner.Text())
+	}
+	hec.batches = append(hec.batches, events)
+	w.Write([]byte(`{"text":"Success","code":0}`))
+}
+
+func (hec *fakeHEC) events() []string {
+	hec.mu.Lock()
+	defer hec.mu.Unlock()
+
+	var events []string
+	for _, batch := range hec.batches {
+		events = append(events, batch...)
+	}
+	return events
+}
+
+func newSplunkTestDestination(t *testing.T, hec *fakeHEC, queueSize int) (*httptest.Server, *SplunkDestination) {
+	server := httptest.NewServer(hec)
+	dest, err := NewSplunkDestination(server.URL+"/services/collector/event", "s3cr3t", queueSize)
+	if err != nil {
+		t.Fatal("Error creating destination", err)
+	}
+	dest.RetryBackoff = time.Millisecond
+	return server, dest
+}
+
+func TestSplunkDestination_batching(t *testing.T) {
+	hec := &fakeHEC{}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	dest.BatchSize = 2
+	dest.FlushInterval = time.Hour
+
+	dest.Info("%s", `{"time":1,"event":{"n":1}}`)
+	dest.Warning("%s", `{"time":2,"event":{"n":2}}`)
+	dest.Error("%s", `{"time":3,"event":{"n":3}}`)
+	dest.Close()
+
+	if len(hec.batches) != 2 || len(hec.batches[0]) != 2 || len(hec.batches[1]) != 1 {
+		t.Error("Events not sent in batches of 2, got", hec.batches)
+	}
+	if hec.batches[0][0] != `{"time":1,"event":{"n":1}}` {
+		t.Error("Event not sent as is, got", hec.batches[0][0])
+	}
+	for _, auth := range hec.auth {
+		if auth != "Splunk s3cr3t" {
+			t.Error("Token not sent, got", auth)
+		}
+	}
+}
+
+func TestSplunkDestination_flushInterval(t *testing.T) {
+	hec := &fakeHEC{}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+	defer dest.Close()
+
+	dest.FlushInterval = 10 * time.Millisecond
+	dest.Info("%s", `{"event":"tick"}`)
+
+	for deadline := time.Now().Add(5 * time.Second); len(hec.events()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Partial batch not sent after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSplunkDestination_wrapsOtherRecords(t *testing.T) {
+	hec := &fakeHEC{}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	dest.Info("%s", `Method: GET Status: 100%`)
+	dest.Info("%s", `{"@fields":{"method":"GET"}}`)
+	dest.Close()
+
+	events := hec.events()
+	if len(events) != 2 || events[0] != `{"event":"Method: GET Status: 100%"}` || events[1] != `{"event":{"@fields":{"method":"GET"}}}` {
+		t.Error("Records not wrapped in events, got", events)
+	}
+}
+
+func TestSplunkDestination_retries(t *testing.T) {
+	hec := &fakeHEC{failures: 2, status: http.StatusServiceUnavailable}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	var errs []error
+	dest.OnError = func(err error) { errs = append(errs, err) }
+	dest.Info("%s", `{"event":"retried"}`)
+	dest.Close()
+
+	if len(hec.auth) != 3 || len(hec.events()) != 1 {
+		t.Error("Batch not retried until it was sent, got", len(hec.auth), "requests")
+	}
+	if len(errs) != 0 {
+		t.Error("Error reported for a batch sent on retry", errs)
+	}
+}
+
+func TestSplunkDestination_givesUp(t *testing.T) {
+	hec := &fakeHEC{failures: 10, status: http.StatusServiceUnavailable}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	var errs []error
+	dest.MaxRetries = 1
+	dest.OnError = func(err error) { errs = append(errs, err) }
+	dest.Info("%s", `{"event":"lost"}`)
+	dest.Close()
+
+	if len(hec.auth) != 2 {
+		t.Error("Batch not retried once, got", len(hec.auth), "requests")
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "503") {
+		t.Error("Failure not reported, got", errs)
+	}
+}
+
+func TestSplunkDestination_noRetryOnClientError(t *testing.T) {
+	hec := &fakeHEC{failures: 10, status: http.StatusForbidden}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	dest.Info("%s", `{"event":"forbidden"}`)
+	dest.Close()
+
+	if len(hec.auth) != 1 {
+		t.Error("Batch retried after a 403, got", len(hec.auth), "requests")
+	}
+}
+
+func TestSplunkDestination_boundedQueue(t *testing.T) {
+	hec := &fakeHEC{block: make(chan struct{})}
+	server, dest := newSplunkTestDestination(t, hec, 2)
+	defer server.Close()
+
+	var mu sync.Mutex
+	dropped := 0
+	dest.BatchSize = 1
+	dest.OnError = func(err error) {
+		mu.Lock()
+		dropped++
+		mu.Unlock()
+	}
+
+	for i := 0; i < 10; i++ {
+		dest.Info("%s", `{"event":"queued"}`)
+	}
+	close(hec.block)
+	dest.Close()
+
+	sent := len(hec.events())
+	if dropped == 0 || sent+dropped != 10 || sent > 3 {
+		t.Error("Queue not bounded, sent", sent, "and dropped", dropped)
+	}
+
+	before := dropped
+	dest.Info("%s", `{"event":"late"}`)
+	if dropped != before+1 || len(hec.events()) != sent {
+		t.Error("Event logged after Close not dropped")
+	}
+}
+
+func TestSplunkDestination_closeTimeout(t *testing.T) {
+	hec := &fakeHEC{block: make(chan struct{})}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+	defer close(hec.block)
+
+	dest.BatchSize = 1
+	dest.CloseTimeout = 50 * time.Millisecond
+	dest.Info("%s", `{"event":"stuck"}`)
+
+	closed := make(chan error)
+	go func() { closed <- dest.Close() }()
+
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Error("Expected an error closing with events still queued")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a hung endpoint")
+	}
+}
+
+func TestSplunkDestination_throughLogger(t *testing.T) {
+	hec := &fakeHEC{}
+	server, dest := newSplunkTestDestination(t, hec, 0)
+	defer server.Close()
+
+	formatter, _ := NewSplunkFormatter("fooApp", "", "")
+	logger, _ := NewHTTPLogger("foo", dest, formatter)
+	res, req := createPercentRequestAndResponse()
+	logger.Info(res, req)
+	dest.Close()
+
+	events := hec.events()
+	if len(events) != 1 || !strings.Contains(events[0], `"path":"/search/100%25?q=100%25\u0026fmt=%s%d"`) {
+		t.Error("Record not sent to Splunk, got", events)
+	}
+}
@@ -0,0 +1,67 @@
+package httpclerk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SplunkFormatter formats records as Splunk HTTP Event Collector events:
+//
+//	{"time":1406447036.123,"host":"web-1","source":"fooApp","sourcetype":"httpclerk","event":{"method":"GET",...}}
+//
+// Send them to Splunk with a SplunkDestination.
+type SplunkFormatter struct {
+	// Defaults to the hostname.
+	Host string
+	// Defaults to the HEC token's default source when empty.
+	Source string
+	// Defaults to "httpclerk".
+	Sourcetype string
+	// Defaults to the HEC token's default index when empty.
+	Index string
+}
+
+// NewSplunkFormatter constructor.
+func NewSplunkFormatter(source, sourcetype, index string) (*SplunkFormatter, error) {
+	return &SplunkFormatter{Source: source, Sourcetype: sourcetype, Index: index}, nil
+}
+
+type splunkEvent struct {
+	Time       float64     `json:"time"`
+	Host       string      `json:"host,omitempty"`
+	Source     string      `json:"source,omitempty"`
+	Sourcetype string      `json:"sourcetype,omitempty"`
+	Index      string      `json:"index,omitempty"`
+	Event      interface{} `json:"event"`
+}
+
+func (formatter *SplunkFormatter) Format(customFields interface{}) (string, error) {
+	event := splunkEvent{
+		Time:       gelfTimestamp(time.Now()),
+		Host:       formatter.Host,
+		Source:     formatter.Source,
+		Sourcetype: formatter.Sourcetype,
+		Index:      formatter.Index,
+		Event:      customFields,
+	}
+
+	if event.Host == "" {
+		event.Host, _ = os.Hostname()
+	}
+	if event.Sourcetype == "" {
+		event.Sourcetype = "httpclerk"
+	}
+	if f, ok := customFields.(*fields); ok {
+		event.Time = gelfTimestamp(f.started)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error marshalling JSON: %s", err))
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,66 @@
+package httpclerk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplunkFormat(t *testing.T) {
+	formatter, _ := NewSplunkFormatter("fooApp", "", "web")
+	formatter.Host = "web-1"
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	res, req := createRequestAndResponse()
+
+	before := time.Now()
+	logger.Info(res, req)
+
+	doc, err := decodeJSONToMap(memBackend.Head().Record.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"host":       "web-1",
+		"source":     "fooApp",
+		"sourcetype": "httpclerk",
+		"index":      "web",
+	}
+	for key, value := range expected {
+		if doc[key] != value {
+			t.Error("Field", key, "not set correctly, expected", value, "got", doc[key])
+		}
+	}
+
+	timestamp := doc["time"].(float64)
+	if timestamp < gelfTimestamp(before) || timestamp > gelfTimestamp(time.Now()) {
+		t.Error("time not set to the request's start, got", timestamp)
+	}
+
+	event := doc["event"].(map[string]interface{})
+	if event["method"] != "PUT" || event["path"] != "/1234.json" || event["host"] != "www.foo.com" {
+		t.Error("Fields not set as the event, got", event)
+	}
+}
+
+func TestSplunkFormat_defaults(t *testing.T) {
+	formatter, _ := NewSplunkFormatter("", "", "")
+
+	data, err := formatter.Format(&testingFields{"hi", "there", 101, []int{1, 2}})
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	doc, _ := decodeJSONToMap(data)
+	if _, ok := doc["index"]; ok {
+		t.Error("Empty index sent, got", doc)
+	}
+	if _, ok := doc["source"]; ok {
+		t.Error("Empty source sent, got", doc)
+	}
+	if doc["host"] == "" || doc["sourcetype"] != "httpclerk" {
+		t.Error("host and sourcetype not defaulted, got", doc)
+	}
+	if doc["event"].(map[string]interface{})["Hot"] != "hi" {
+		t.Error("Fields not set as the event, got", doc["event"])
+	}
+}
@@ -0,0 +1,134 @@
+package httpclerk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StructuredDestination takes records as typed values instead of formatted
+// strings, so backends that encode records themselves don't get JSON inside
+// JSON. Use one with NewStructuredHTTPLogger.
+type StructuredDestination interface {
+	LogRecord(Record)
+}
+
+// Record is what HTTPLogger hands a StructuredDestination.
+type Record struct {
+	Level Level
+	// When the request started.
+	Time time.Time
+	// "METHOD PATH STATUS"
+	Message string
+	// The fields, built-in ones first, in the order formatters log them,
+	// then custom fields sorted by key. Headers, timings and other nested
+	// values are groups, with a []Attr value sorted by key.
+	Attrs []Attr
+
+	fields  *fields
+	request *http.Request
+}
+
+// Attr is a key and value of a Record.
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// NewStructuredHTTPLogger constructor, for a destination that takes records
+// without a Formatter.
+func NewStructuredHTTPLogger(name string, destination StructuredDestination) (*HTTPLogger, error) {
+	if destination == nil {
+		return nil, errors.New(fmt.Sprintf("No destination given for logger %s", name))
+	}
+
+	log, err := newHTTPLogger(name)
+	if err != nil {
+		return nil, err
+	}
+	log.destination = destination
+	return log, nil
+}
+
+// Leaves Attrs empty, as a fieldsDestination goes by the fields instead;
+// see newAttrs.
+func newRecord(f *fields, req *http.Request) Record {
+	return Record{
+		Level:   f.level,
+		Time:    f.started,
+		Message: logStashMessage(f),
+		fields:  f,
+		request: req,
+	}
+}
+
+func newAttrs(f *fields) []Attr {
+	kvs := f.keyValues()
+	attrs := make([]Attr, 0, len(kvs))
+	for _, kv := range kvs {
+		attrs = append(attrs, newAttr(kv.key, kv.value))
+	}
+	return attrs
+}
+
+func newAttr(key string, value interface{}) Attr {
+	switch v := value.(type) {
+	case map[string][]string:
+		group := make([]Attr, 0, len(v))
+		for _, name := range sortedStringSliceKeys(v) {
+			group = append(group, Attr{name, v[name]})
+		}
+		return Attr{key, group}
+	case map[string]interface{}:
+		group := make([]Attr, 0, len(v))
+		for _, name := range sortedKeys(v) {
+			group = append(group, newAttr(name, v[name]))
+		}
+		return Attr{key, group}
+	default:
+		return Attr{key, v}
+	}
+}
+
+// A destination that goes by a record's fields rather than its Attrs, so
+// HTTPLogger can skip building them.
+type fieldsDestination interface {
+	StructuredDestination
+	usesFields()
+}
+
+// Bridges a Formatter and LogDestination to StructuredDestination, so
+// NewHTTPLogger works as it always has.
+type formattedDestination struct {
+	log         *HTTPLogger
+	formatter   Formatter
+	destination LogDestination
+}
+
+func (dest *formattedDestination) usesFields() {}
+
+func (dest *formattedDestination) LogRecord(record Record) {
+	data, err := dest.formatter.Format(record.fields)
+	if err != nil {
+		dest.log.formatError(err, record, dest)
+		return
+	}
+
+	dest.emit(record.Level, data)
+}
+
+func (dest *formattedDestination) emit(level Level, data string) {
+	switch level {
+	case LevelDebug:
+		dest.destination.Debug("%s", data)
+	case LevelWarning:
+		dest.destination.Warning("%s", data)
+	case LevelError:
+		dest.destination.Error("%s", data)
+	case LevelCritical:
+		dest.destination.Critical("%s", data)
+	default:
+		dest.destination.Info("%s", data)
+	}
+}
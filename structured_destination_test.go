@@ -0,0 +1,99 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Keeps every record it's given.
+type recordingDestination struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (dest *recordingDestination) LogRecord(record Record) {
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+	dest.records = append(dest.records, record)
+}
+
+func TestStructuredHTTPLogger(t *testing.T) {
+	dest := &recordingDestination{}
+	logger, _ := NewStructuredHTTPLogger("foo", dest)
+	logger.FieldExtractors = []FieldExtractor{tenantExtractor}
+	req := httptest.NewRequest("GET", "http://www.foo.com/items?token=abc", nil)
+	req.Header.Set("X-B", "2")
+	req.Header.Set("X-A", "1")
+
+	before := time.Now()
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddTiming(r.Context(), "db", 2*time.Millisecond)
+		AddTiming(r.Context(), "cache", time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(dest.records) != 1 {
+		t.Fatal("Expected 1 record, got", len(dest.records))
+	}
+	record := dest.records[0]
+
+	if record.Level != LevelWarning || record.Message != "GET /items?token=REDACTED 404" {
+		t.Error("Record not set correctly, got", record.Level, record.Message)
+	}
+	if record.Time.Before(before) || record.Time.After(time.Now()) {
+		t.Error("Time not set to the request's start, got", record.Time)
+	}
+
+	var keys []string
+	attrs := map[string]interface{}{}
+	for _, attr := range record.Attrs {
+		keys = append(keys, attr.Key)
+		attrs[attr.Key] = attr.Value
+	}
+
+	expectedKeys := []string{"method", "status", "path", "host", "headers", "bytes_in", "bytes_out", "duration", "remote_addr", "protocol", "tenant", "tenant_id", "timings"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Error("Attrs not in order, expected", expectedKeys, "got", keys)
+	}
+
+	if attrs["method"] != "GET" || attrs["status"] != "404" || attrs["tenant"] != "acme" {
+		t.Error("Attrs not set correctly, got", attrs)
+	}
+
+	expectedHeaders := []Attr{{"X-A", []string{"1"}}, {"X-B", []string{"2"}}}
+	if !reflect.DeepEqual(attrs["headers"], expectedHeaders) {
+		t.Error("Headers not a sorted group, expected", expectedHeaders, "got", attrs["headers"])
+	}
+
+	expectedTimings := []Attr{{"cache", 1.0}, {"db", 2.0}}
+	if !reflect.DeepEqual(attrs["timings"], expectedTimings) {
+		t.Error("Timings not a sorted group, expected", expectedTimings, "got", attrs["timings"])
+	}
+}
+
+func TestStructuredHTTPLogger_levelMethods(t *testing.T) {
+	dest := &recordingDestination{}
+	logger, _ := NewStructuredHTTPLogger("foo", dest)
+	res, req := createPercentRequestAndResponse()
+
+	logger.Debug(res, req)
+	logger.Critical(res, req)
+
+	if dest.records[0].Level != LevelDebug || dest.records[1].Level != LevelCritical {
+		t.Error("Levels not set from the method, got", dest.records[0].Level, dest.records[1].Level)
+	}
+	if dest.records[0].Message != "GET /search/100%25?q=100%25&fmt=%s%d" {
+		t.Error("Message not set correctly, got", dest.records[0].Message)
+	}
+}
+
+func TestNewStructuredHTTPLogger_noDestination(t *testing.T) {
+	if _, err := NewStructuredHTTPLogger("foo", nil); err == nil {
+		t.Error("Expected an error creating a logger without a destination")
+	}
+}
@@ -0,0 +1,177 @@
+package httpclerk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogFormatter formats records as RFC 5424 syslog messages, with the
+// fields in a STRUCTURED-DATA element:
+//
+//	<134>1 2014-07-27T07:43:56.123456Z web-1 fooApp 4242 - [http@32473 method="GET" status="200" path="/foo" ...] GET /foo 200
+//
+// The PRI is worked out from Facility and the level the record is logged at.
+// Headers and other nested values are flattened to names such as
+// header.user_agent. Set OctetCounting to frame messages for TCP or TLS
+// transports, as described in RFC 5425.
+type SyslogFormatter struct {
+	// Defaults to the hostname.
+	Hostname string
+	AppName  string
+	// Defaults to "-".
+	MsgID string
+	// Defaults to 16, local0. Use 1 for user.
+	Facility int
+	// Defaults to "http@32473", under the example enterprise number.
+	SDID string
+	// Prefixes each message with its length, e.g. "87 <134>1 ...".
+	OctetCounting bool
+}
+
+// NewSyslogFormatter constructor.
+func NewSyslogFormatter(appName, msgID string) (*SyslogFormatter, error) {
+	return &SyslogFormatter{AppName: appName, MsgID: msgID, Facility: 16}, nil
+}
+
+// Syslog severities for each level.
+var syslogSeverities = map[Level]int{
+	LevelDebug:    7,
+	LevelInfo:     6,
+	LevelWarning:  4,
+	LevelError:    3,
+	LevelCritical: 2,
+}
+
+// Like time.RFC3339Nano, but with a fixed 6 digits, the most RFC 5424 allows.
+const syslogTime = "2006-01-02T15:04:05.000000Z07:00"
+
+func (formatter *SyslogFormatter) Format(customFields interface{}) (string, error) {
+	level, started := LevelInfo, time.Now()
+	var kvs []keyValue
+
+	f, ok := customFields.(*fields)
+	if ok {
+		level, started = f.level, f.started
+		kvs = f.keyValues()
+	} else {
+		m, err := toJSONMap(customFields)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range sortedKeys(m) {
+			kvs = append(kvs, keyValue{key, m[key]})
+		}
+	}
+
+	facility := formatter.Facility
+	if facility <= 0 || facility > 23 {
+		facility = 16
+	}
+
+	hostname := formatter.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	sdID := formatter.SDID
+	if sdID == "" {
+		sdID = "http@32473"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s [%s",
+		facility*8+syslogSeverities[level],
+		started.Format(syslogTime),
+		syslogHeaderField(hostname, 255),
+		syslogHeaderField(formatter.AppName, 48),
+		os.Getpid(),
+		syslogHeaderField(formatter.MsgID, 32),
+		syslogName(sdID),
+	)
+	for _, kv := range kvs {
+		key := kv.key
+		if key == "headers" {
+			key = "header"
+		}
+		writeSyslogParam(&buf, key, kv.value)
+	}
+	buf.WriteString("] ")
+	buf.WriteString(logStashMessage(customFields))
+
+	if formatter.OctetCounting {
+		return strconv.Itoa(buf.Len()) + " " + buf.String(), nil
+	}
+	return buf.String(), nil
+}
+
+func writeSyslogParam(buf *bytes.Buffer, name string, value interface{}) {
+	switch v := value.(type) {
+	case map[string][]string:
+		for _, key := range sortedStringSliceKeys(v) {
+			writeSyslogParam(buf, name+"."+strings.ToLower(key), strings.Join(v[key], ", "))
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			writeSyslogParam(buf, name+"."+key, v[key])
+		}
+	default:
+		buf.WriteByte(' ')
+		buf.WriteString(syslogName(name))
+		buf.WriteString(`="`)
+		syslogParamEscaper.WriteString(buf, syslogValue(value))
+		buf.WriteByte('"')
+	}
+}
+
+func syslogValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// PARAM-VALUE escapes '"', '\' and ']' with a backslash.
+var syslogParamEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// SD-NAMEs are up to 32 printable ASCII characters, except '=', ' ', ']' and '"'.
+func syslogName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// Header fields are printable ASCII, or "-" when empty.
+func syslogHeaderField(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
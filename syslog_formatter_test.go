@@ -0,0 +1,125 @@
+package httpclerk
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogFormat(t *testing.T) {
+	formatter, _ := NewSyslogFormatter("fooApp", "access")
+	formatter.Hostname = "web-1"
+
+	f := &fields{
+		Method:   "GET",
+		Status:   "200",
+		Path:     "/x",
+		Host:     "www.foo.com",
+		Headers:  map[string][]string{"User-Agent": []string{"curl/7.30.0"}},
+		BytesOut: 12,
+		Duration: 1.5,
+		Extra:    map[string]interface{}{"timings": map[string]interface{}{"db": 0.5}},
+		started:  time.Date(2014, 7, 27, 7, 43, 56, 123456789, time.UTC),
+		level:    LevelInfo,
+	}
+
+	data, err := formatter.Format(f)
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	expected := fmt.Sprintf(`<134>1 2014-07-27T07:43:56.123456Z web-1 fooApp %d access `+
+		`[http@32473 method="GET" status="200" path="/x" host="www.foo.com" header.user-agent="curl/7.30.0" `+
+		`bytes_in="0" bytes_out="12" duration="1.5" timings.db="0.5"] GET /x 200`, os.Getpid())
+	if data != expected {
+		t.Error("Message not formatted correctly, expected\n", expected, "\ngot\n", data)
+	}
+}
+
+func TestSyslogFormat_priority(t *testing.T) {
+	formatter, _ := NewSyslogFormatter("fooApp", "")
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	res, req := createRequestAndResponse()
+
+	logger.Debug(res, req)
+	logger.Info(res, req)
+	logger.Warning(res, req)
+	logger.Error(res, req)
+	logger.Critical(res, req)
+
+	expected := []string{"<135>1 ", "<134>1 ", "<132>1 ", "<131>1 ", "<130>1 "}
+	i := 0
+	for node := memBackend.Head(); node != nil; node = node.Next() {
+		if !strings.HasPrefix(node.Record.Message(), expected[i]) {
+			t.Error("PRI not worked out from the level, expected", expected[i], "got", node.Record.Message())
+		}
+		i++
+	}
+
+	formatter.Facility = 1
+	data, _ := formatter.Format(&fields{Method: "GET", level: LevelError})
+	if !strings.HasPrefix(data, "<11>1 ") {
+		t.Error("PRI not worked out from the facility, got", data)
+	}
+}
+
+func TestSyslogFormat_escaping(t *testing.T) {
+	formatter, _ := NewSyslogFormatter("foo app", "")
+	formatter.Hostname = "web-1"
+
+	f := &fields{
+		Method: "GET",
+		Path:   `/a"b]c\d`,
+		Extra:  map[string]interface{}{"odd key=1": "x", "a_very_long_custom_field_name_over_32": "y"},
+	}
+
+	data, _ := formatter.Format(f)
+
+	for _, part := range []string{
+		` foo_app `,
+		` - [http@32473 `,
+		` path="/a\"b\]c\\d" `,
+		` odd_key_1="x"`,
+		` a_very_long_custom_field_name_ov="y"`,
+		` status="" `,
+	} {
+		if !strings.Contains(data, part) {
+			t.Error("Expected", part, "in", data)
+		}
+	}
+}
+
+func TestSyslogFormat_octetCounting(t *testing.T) {
+	formatter, _ := NewSyslogFormatter("fooApp", "")
+	formatter.OctetCounting = true
+
+	data, _ := formatter.Format(&fields{Method: "GET", Path: "/ü", started: time.Now()})
+
+	r := regexp.MustCompile(`^(\d+) (<\d+>1 .*)$`)
+	match := r.FindStringSubmatch(data)
+	if match == nil {
+		t.Fatal("Message not framed, got", data)
+	}
+	if length, _ := strconv.Atoi(match[1]); length != len(match[2]) {
+		t.Error("Frame length doesn't count octets, expected", len(match[2]), "got", length)
+	}
+}
+
+func TestSyslogFormat_otherFields(t *testing.T) {
+	formatter, _ := NewSyslogFormatter("fooApp", "")
+	formatter.Hostname = "web-1"
+	formatter.SDID = "custom@1"
+
+	data, err := formatter.Format(&testingFields{"hi", "there", 101, []int{1, 2}})
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	if !strings.Contains(data, `[custom@1 Blip="[1 2\]" Block="101" Chip="there" Hot="hi"]`) {
+		t.Error("Fields not set as structured data, got", data)
+	}
+}
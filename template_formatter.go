@@ -0,0 +1,259 @@
+package httpclerk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFormatter formats records with a layout compiled once, up front,
+// so a team can match its existing access log without writing a Formatter.
+// Create one with NewTemplateFormatter or NewNginxFormatter.
+type TemplateFormatter struct {
+	tmpl     *template.Template
+	segments []nginxSegment
+}
+
+// NewTemplateFormatter compiles a text/template layout. Fields are available
+// under their JSON keys, custom fields included, along with the time the
+// request started and the level it is logged at. Headers hold every value
+// sent; the header function returns the first, or "" when the header is
+// missing:
+//
+//	{{.time.Format "15:04:05"}} {{.level}} {{.method}} {{.path}} {{.status}} {{header .headers "User-Agent"}}
+//
+// time and level take precedence over custom fields of the same name.
+func NewTemplateFormatter(layout string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("httpclerk").Funcs(templateFuncs).Parse(layout)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+// NewNginxFormatter compiles an nginx log_format style layout, e.g.
+//
+//	$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer"
+//
+// Variables can be nginx's own (request_method, request_uri, uri, args,
+// remote_addr, remote_user, time_local, time_iso8601, msec, request,
+// request_time, request_length, body_bytes_sent, server_protocol, host and
+// http_<header>) or any field's JSON key, custom fields included. A
+// variable with no value is logged as "-".
+func NewNginxFormatter(layout string) (*TemplateFormatter, error) {
+	segments, err := parseNginxLayout(layout)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{segments: segments}, nil
+}
+
+func (formatter *TemplateFormatter) Format(customFields interface{}) (string, error) {
+	f, ok := customFields.(*fields)
+	if !ok {
+		m, err := toJSONMap(customFields)
+		if err != nil {
+			return "", err
+		}
+		f = &fields{Extra: m, started: time.Now(), level: LevelInfo}
+	}
+
+	if formatter.tmpl == nil {
+		return formatter.formatNginx(f), nil
+	}
+
+	var buf bytes.Buffer
+	err := formatter.tmpl.Execute(&buf, templateData(f))
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error executing template: %s", err))
+	}
+
+	return buf.String(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	"header": func(headers map[string][]string, name string) string {
+		values := headerValues(headers, name)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	},
+}
+
+func templateData(f *fields) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, kv := range f.keyValues() {
+		data[kv.key] = kv.value
+	}
+	data["time"] = f.started
+	data["level"] = f.level.String()
+	return data
+}
+
+// Either literal text or the name of a variable.
+type nginxSegment struct {
+	text     string
+	variable bool
+}
+
+func parseNginxLayout(layout string) ([]nginxSegment, error) {
+	var segments []nginxSegment
+	var literal bytes.Buffer
+
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '$' {
+			literal.WriteByte(layout[i])
+			continue
+		}
+
+		var name string
+		if i+1 < len(layout) && layout[i+1] == '{' {
+			end := strings.IndexByte(layout[i:], '}')
+			if end < 0 {
+				return nil, errors.New(fmt.Sprintf("Unclosed variable at %d in layout %q", i, layout))
+			}
+			name = layout[i+2 : i+end]
+			i += end
+		} else {
+			end := i + 1
+			for end < len(layout) && isNginxNameByte(layout[end]) {
+				end++
+			}
+			name = layout[i+1 : end]
+			i = end - 1
+		}
+
+		if name == "" {
+			return nil, errors.New(fmt.Sprintf("Empty variable name in layout %q", layout))
+		}
+
+		if literal.Len() > 0 {
+			segments = append(segments, nginxSegment{text: literal.String()})
+			literal.Reset()
+		}
+		segments = append(segments, nginxSegment{text: name, variable: true})
+	}
+
+	if literal.Len() > 0 {
+		segments = append(segments, nginxSegment{text: literal.String()})
+	}
+
+	return segments, nil
+}
+
+func isNginxNameByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (formatter *TemplateFormatter) formatNginx(f *fields) string {
+	var data map[string]interface{}
+	var buf bytes.Buffer
+
+	for _, segment := range formatter.segments {
+		if !segment.variable {
+			buf.WriteString(segment.text)
+			continue
+		}
+
+		value, ok := nginxVariable(f, segment.text)
+		if !ok {
+			if data == nil {
+				data = templateData(f)
+			}
+			if v := data[segment.text]; v != nil {
+				value = fmt.Sprint(v)
+			}
+		}
+
+		buf.WriteString(orDash(value))
+	}
+
+	return buf.String()
+}
+
+func nginxVariable(f *fields, name string) (string, bool) {
+	path, query := f.Path, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	if f.Query != nil {
+		query = url.Values(f.Query).Encode()
+	}
+
+	switch name {
+	case "request_method":
+		return f.Method, true
+	case "request_uri":
+		if query != "" {
+			return path + "?" + query, true
+		}
+		return path, true
+	case "uri":
+		return path, true
+	case "args", "query_string":
+		return query, true
+	case "remote_addr":
+		host, _, err := net.SplitHostPort(f.RemoteAddr)
+		if err != nil {
+			return f.RemoteAddr, true
+		}
+		return host, true
+	case "remote_user":
+		return f.User, true
+	case "time_local":
+		return f.started.Format(commonLogTime), true
+	case "time_iso8601":
+		return f.started.Format(time.RFC3339), true
+	case "msec":
+		return strconv.FormatFloat(gelfTimestamp(f.started), 'f', 3, 64), true
+	case "request":
+		uri := path
+		if query != "" {
+			uri += "?" + query
+		}
+		return f.Method + " " + uri + " " + f.Protocol, true
+	case "request_time":
+		if f.elapsed == 0 {
+			return "", true
+		}
+		return strconv.FormatFloat(f.elapsed.Seconds(), 'f', 3, 64), true
+	case "request_length":
+		return strconv.FormatInt(f.BytesIn, 10), true
+	case "body_bytes_sent":
+		return strconv.FormatInt(f.BytesOut, 10), true
+	case "server_protocol":
+		return f.Protocol, true
+	case "http_referer":
+		return f.Referer, true
+	case "http_user_agent":
+		return f.UserAgent, true
+	}
+
+	if strings.HasPrefix(name, "http_") {
+		header := strings.Replace(strings.TrimPrefix(name, "http_"), "_", "-", -1)
+		return strings.Join(headerValues(f.Headers, header), ", "), true
+	}
+
+	return "", false
+}
+
+// Looks a header up case-insensitively, as a policy may have kept keys the
+// way they were configured rather than canonicalized.
+func headerValues(headers map[string][]string, name string) []string {
+	if values, ok := headers[name]; ok {
+		return values
+	}
+	for key, values := range headers {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
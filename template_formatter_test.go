@@ -0,0 +1,153 @@
+package httpclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func templateFields() *fields {
+	return &fields{
+		Method:     "GET",
+		Status:     "200",
+		Path:       "/search?q=go",
+		Host:       "www.foo.com",
+		Headers:    map[string][]string{"X-Request-Id": []string{"req-1"}},
+		BytesIn:    12,
+		BytesOut:   2326,
+		Duration:   12.5,
+		RemoteAddr: "127.0.0.1:51234",
+		Protocol:   "HTTP/1.1",
+		User:       "frank",
+		UserAgent:  "curl/7.30.0",
+		Extra:      map[string]interface{}{"tenant": "acme"},
+		started:    time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+		elapsed:    12500 * time.Microsecond,
+		level:      LevelWarning,
+	}
+}
+
+func TestTemplateFormat(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`{{.time.Format "15:04:05"}} {{.level}} {{.method}} {{.path}} {{.status}} {{.duration}}ms {{index .headers "X-Request-Id" 0}} tenant={{.tenant}}`)
+	if err != nil {
+		t.Fatal("Error compiling template", err)
+	}
+
+	data, err := formatter.Format(templateFields())
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	expected := `13:55:36 WARNING GET /search?q=go 200 12.5ms req-1 tenant=acme`
+	if data != expected {
+		t.Error("Fields not formatted correctly, expected", expected, "got", data)
+	}
+}
+
+func TestTemplateFormat_reservedNames(t *testing.T) {
+	formatter, _ := NewTemplateFormatter(`{{.level}} {{.time.Year}}`)
+	f := templateFields()
+	f.Extra = map[string]interface{}{"level": "custom", "time": "custom"}
+
+	data, err := formatter.Format(f)
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	if data != "WARNING 2000" {
+		t.Error("time and level not taking precedence over custom fields, got", data)
+	}
+}
+
+func TestTemplateFormat_header(t *testing.T) {
+	formatter, _ := NewTemplateFormatter(`[{{header .headers "x-request-id"}}] [{{header .headers "User-Agent"}}]`)
+
+	data, err := formatter.Format(templateFields())
+	if err != nil {
+		t.Error("Error formatting fields", err)
+	}
+
+	if data != "[req-1] []" {
+		t.Error("Headers not formatted correctly, got", data)
+	}
+}
+
+func TestTemplateFormat_badTemplate(t *testing.T) {
+	if _, err := NewTemplateFormatter(`{{.method`); err == nil {
+		t.Error("Expected an error compiling a bad template")
+	}
+
+	formatter, _ := NewTemplateFormatter(`{{.method.Nope}}`)
+	if _, err := formatter.Format(templateFields()); err == nil {
+		t.Error("Expected an error executing a bad template")
+	}
+}
+
+func TestNginxFormat(t *testing.T) {
+	formatter, err := NewNginxFormatter(`$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" $request_time ${tenant}x $http_x_request_id $missing`)
+	if err != nil {
+		t.Fatal("Error compiling layout", err)
+	}
+
+	data, _ := formatter.Format(templateFields())
+
+	expected := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /search?q=go HTTP/1.1" 200 2326 "-" "curl/7.30.0" 0.013 acmex req-1 -`
+	if data != expected {
+		t.Error("Fields not formatted correctly, expected\n", expected, "\ngot\n", data)
+	}
+}
+
+func TestNginxFormat_fieldNames(t *testing.T) {
+	formatter, _ := NewNginxFormatter(`$method $status $request_time $uri $args $request_length`)
+
+	data, _ := formatter.Format(templateFields())
+
+	if data != `GET 200 0.013 /search q=go 12` {
+		t.Error("Fields not formatted correctly, got", data)
+	}
+}
+
+func TestNginxFormat_badLayout(t *testing.T) {
+	for _, layout := range []string{`${method`, `$ $status`, `${}`} {
+		if _, err := NewNginxFormatter(layout); err == nil {
+			t.Error("Expected an error compiling", layout)
+		}
+	}
+}
+
+func TestNginxFormat_throughMiddleware(t *testing.T) {
+	formatter, _ := NewNginxFormatter(`$request_method $request_uri $status $request_time`)
+	memBackend, logger := loadLoggerWithFormatter(formatter)
+	req := httptest.NewRequest("GET", "http://www.foo.com/items?token=abc", nil)
+
+	logger.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	r := regexp.MustCompile(`^GET /items\?token=REDACTED 404 \d+\.\d{3}$`)
+	if lastWrite := memBackend.Head().Record.Message(); !r.MatchString(lastWrite) {
+		t.Error("Request not formatted correctly, got", lastWrite)
+	}
+}
+
+func TestNginxFormat_structuredQuery(t *testing.T) {
+	formatter, _ := NewNginxFormatter(`"$request"`)
+	f := templateFields()
+	f.Path = "/search"
+	f.Query = map[string][]string{"q": []string{"go"}}
+
+	data, _ := formatter.Format(f)
+
+	if data != `"GET /search?q=go HTTP/1.1"` {
+		t.Error("Query missing from the request line, got", data)
+	}
+}
+
+func TestTemplateFormat_otherFields(t *testing.T) {
+	formatter, _ := NewTemplateFormatter(`{{.Hot}} {{.Block}}`)
+
+	data, _ := formatter.Format(&testingFields{"hi", "there", 101, nil})
+	if data != "hi 101" {
+		t.Error("Fields not formatted correctly, got", data)
+	}
+}
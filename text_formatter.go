@@ -14,8 +14,23 @@ func NewTextFormatter(appName string) (*TextFormatter, error) {
 }
 
 func (f *fields) String() string {
-	data := `Method: %s Path: %s Status: %s Host: %s Headers: %s`
-	return fmt.Sprintf(data, f.Method, f.Path, f.Status, f.Host, f.Headers)
+	duration := f.Duration
+	if duration == nil {
+		duration = ""
+	}
+
+	data := `Method: %s Path: %s Status: %s Host: %s BytesIn: %d BytesOut: %d Duration: %v Headers: %s`
+	data = fmt.Sprintf(data, f.Method, f.Path, f.Status, f.Host, f.BytesIn, f.BytesOut, duration, f.Headers)
+
+	if f.Query != nil {
+		data += fmt.Sprintf(" Query: %s", f.Query)
+	}
+
+	for _, key := range sortedKeys(f.Extra) {
+		data += fmt.Sprintf(" %s: %v", key, f.Extra[key])
+	}
+
+	return data
 }
 
 func (f *TextFormatter) Format(customFields interface{}) (string, error) {
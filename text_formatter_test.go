@@ -9,11 +9,14 @@ func TestTextFormat(t *testing.T) {
 	formatter, _ := NewTextFormatter("testApp")
 
 	fields := &fields{
-		"GET",
-		"200",
-		"/foo/bar",
-		"dill.on.com",
-		map[string][]string{"X-Foo": []string{"Gaz"}, "X-Baz": []string{"Blerg"}},
+		Method:   "GET",
+		Status:   "200",
+		Path:     "/foo/bar",
+		Host:     "dill.on.com",
+		Headers:  map[string][]string{"X-Foo": []string{"Gaz"}, "X-Baz": []string{"Blerg"}},
+		BytesIn:  12,
+		BytesOut: 345,
+		Duration: 6.5,
 	}
 
 	data, err := formatter.Format(fields)
@@ -23,7 +26,7 @@ func TestTextFormat(t *testing.T) {
 	}
 
 	// Expecting similar to this...
-	// testApp 1974-carcher.local > Method: GET Path: /foo/bar Status: 200 Host: dill.on.com Headers: map[X-Foo:[Gaz] X-Baz:[Blerg]]
+	// testApp 1974-carcher.local > Method: GET Path: /foo/bar Status: 200 Host: dill.on.com BytesIn: 12 BytesOut: 345 Duration: 6.5 Headers: map[X-Foo:[Gaz] X-Baz:[Blerg]]
 	// We expect the timestamp to be prepended by the log backend.
 
 	r, _ := regexp.Compile(`testApp`)
@@ -51,6 +54,16 @@ func TestTextFormat(t *testing.T) {
 		t.Error("Path not formatted correctly.")
 	}
 
+	r, _ = regexp.Compile(`BytesIn: 12 BytesOut: 345`)
+	if !r.MatchString(data) {
+		t.Error("Bytes not formatted correctly.")
+	}
+
+	r, _ = regexp.Compile(`Duration: 6.5`)
+	if !r.MatchString(data) {
+		t.Error("Duration not formatted correctly.")
+	}
+
 	r, _ = regexp.Compile(`Headers: map(.)*`)
 	if !r.MatchString(data) {
 		t.Error("Headers not formatted correctly.")
@@ -0,0 +1,70 @@
+package httpclerk
+
+import (
+	"io"
+	"sync"
+)
+
+// WriterDestination writes each record as a line to an io.Writer, such as
+// os.Stdout, a file or a bytes.Buffer, so go-logging isn't needed. Writes
+// are serialized, so lines logged by concurrent requests never interleave.
+type WriterDestination struct {
+	writer io.Writer
+
+	// Written at the start of lines logged at a level, e.g. {LevelError: "ERROR "}.
+	Prefixes map[Level]string
+	// Records logged at a lower level are dropped. The zero value drops none.
+	MinLevel Level
+	// Called, if set, with every error writing a line.
+	OnError func(error)
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriterDestination constructor.
+func NewWriterDestination(writer io.Writer) (*WriterDestination, error) {
+	return &WriterDestination{writer: writer, Prefixes: map[Level]string{}}, nil
+}
+
+func (dest *WriterDestination) Debug(data string, args ...interface{}) {
+	dest.write(LevelDebug, sprintf(data, args))
+}
+
+func (dest *WriterDestination) Info(data string, args ...interface{}) {
+	dest.write(LevelInfo, sprintf(data, args))
+}
+
+func (dest *WriterDestination) Warning(data string, args ...interface{}) {
+	dest.write(LevelWarning, sprintf(data, args))
+}
+
+func (dest *WriterDestination) Error(data string, args ...interface{}) {
+	dest.write(LevelError, sprintf(data, args))
+}
+
+func (dest *WriterDestination) Critical(data string, args ...interface{}) {
+	dest.write(LevelCritical, sprintf(data, args))
+}
+
+// Each line goes out in a single Write, so writers shared with other
+// processes, like a file opened with O_APPEND, see whole lines too.
+func (dest *WriterDestination) write(level Level, record string) {
+	if level < dest.MinLevel {
+		return
+	}
+
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+
+	dest.buf = append(dest.buf[:0], dest.Prefixes[level]...)
+	dest.buf = append(dest.buf, record...)
+	if len(record) == 0 || record[len(record)-1] != '\n' {
+		dest.buf = append(dest.buf, '\n')
+	}
+
+	_, err := dest.writer.Write(dest.buf)
+	if err != nil && dest.OnError != nil {
+		dest.OnError(err)
+	}
+}
@@ -0,0 +1,112 @@
+package httpclerk
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriterDestination(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewWriterDestination(&buf)
+
+	dest.Info("%s", "first 100%")
+	dest.Warning("%s", "second\n")
+	dest.Error("third %d", 3)
+
+	if buf.String() != "first 100%\nsecond\nthird 3\n" {
+		t.Error("Records not written as lines, got", buf.String())
+	}
+}
+
+func TestWriterDestination_prefixes(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewWriterDestination(&buf)
+	dest.Prefixes[LevelError] = "ERROR "
+	dest.Prefixes[LevelCritical] = "CRITICAL "
+
+	dest.Info("%s", "fine")
+	dest.Error("%s", "broken")
+	dest.Critical("%s", "on fire")
+
+	if buf.String() != "fine\nERROR broken\nCRITICAL on fire\n" {
+		t.Error("Prefixes not written, got", buf.String())
+	}
+}
+
+func TestWriterDestination_minLevel(t *testing.T) {
+	var buf bytes.Buffer
+	dest, _ := NewWriterDestination(&buf)
+	dest.MinLevel = LevelWarning
+
+	dest.Debug("%s", "debug")
+	dest.Info("%s", "info")
+	dest.Warning("%s", "warning")
+	dest.Critical("%s", "critical")
+
+	if buf.String() != "warning\ncritical\n" {
+		t.Error("Records below the minimum level not dropped, got", buf.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriterDestination_onError(t *testing.T) {
+	dest, _ := NewWriterDestination(failingWriter{})
+
+	var errs []error
+	dest.OnError = func(err error) { errs = append(errs, err) }
+	dest.Info("%s", "lost")
+
+	if len(errs) != 1 || errs[0].Error() != "disk full" {
+		t.Error("Write error not reported, got", errs)
+	}
+}
+
+// Records each Write, to check lines are written whole.
+type writeRecorder struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriterDestination_concurrentRequests(t *testing.T) {
+	writer := &writeRecorder{}
+	dest, _ := NewWriterDestination(writer)
+	dest.Prefixes[LevelInfo] = "INFO "
+
+	formatter, _ := NewLogStashFormatter("fooApp", []string{})
+	logger, _ := NewHTTPLogger("foo", dest, formatter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, req := createRequestAndResponse()
+			logger.Info(res, req)
+		}()
+	}
+	wg.Wait()
+
+	if len(writer.writes) != 50 {
+		t.Fatal("Expected 50 lines, got", len(writer.writes))
+	}
+	for _, line := range writer.writes {
+		if !strings.HasPrefix(line, "INFO {") || strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "}\n") {
+			t.Error("Line not written whole, got", line)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package zapclerk logs httpclerk records to a zap Logger.
+package zapclerk
+
+import (
+	"github.com/zendesk/go-httpclerk"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Destination logs records to a zap Logger.
+//
+// With httpclerk.NewHTTPLogger, it logs the formatted record as the message.
+// With httpclerk.NewStructuredHTTPLogger, the message is "METHOD PATH STATUS"
+// and the fields are logged as zap fields under an "http" namespace.
+//
+// Critical records are logged at Error, as zap's higher levels panic or exit.
+type Destination struct {
+	logger *zap.Logger
+	sugar  *zap.SugaredLogger
+}
+
+// NewDestination constructor.
+func NewDestination(logger *zap.Logger) (*Destination, error) {
+	return &Destination{logger: logger, sugar: logger.Sugar()}, nil
+}
+
+var levels = map[httpclerk.Level]zapcore.Level{
+	httpclerk.LevelDebug:    zapcore.DebugLevel,
+	httpclerk.LevelInfo:     zapcore.InfoLevel,
+	httpclerk.LevelWarning:  zapcore.WarnLevel,
+	httpclerk.LevelError:    zapcore.ErrorLevel,
+	httpclerk.LevelCritical: zapcore.ErrorLevel,
+}
+
+func (dest *Destination) Debug(data string, args ...interface{}) {
+	dest.sugar.Debugf(data, args...)
+}
+
+func (dest *Destination) Info(data string, args ...interface{}) {
+	dest.sugar.Infof(data, args...)
+}
+
+func (dest *Destination) Warning(data string, args ...interface{}) {
+	dest.sugar.Warnf(data, args...)
+}
+
+func (dest *Destination) Error(data string, args ...interface{}) {
+	dest.sugar.Errorf(data, args...)
+}
+
+func (dest *Destination) Critical(data string, args ...interface{}) {
+	dest.sugar.Errorf(data, args...)
+}
+
+func (dest *Destination) LogRecord(record httpclerk.Record) {
+	entry := dest.logger.Check(levels[record.Level], record.Message)
+	if entry == nil {
+		return
+	}
+	entry.Time = record.Time
+
+	fields := make([]zap.Field, 0, len(record.Attrs)+1)
+	fields = append(fields, zap.Namespace("http"))
+	for _, attr := range record.Attrs {
+		fields = append(fields, field(attr))
+	}
+	entry.Write(fields...)
+}
+
+// Groups, like headers and timings, become nested objects.
+func field(attr httpclerk.Attr) zap.Field {
+	group, ok := attr.Value.([]httpclerk.Attr)
+	if !ok {
+		return zap.Any(attr.Key, attr.Value)
+	}
+
+	return zap.Object(attr.Key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, a := range group {
+			field(a).AddTo(enc)
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,113 @@
+package zapclerk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zendesk/go-httpclerk"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func loadDestination() (*observer.ObservedLogs, *Destination) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dest, _ := NewDestination(zap.New(core))
+	return logs, dest
+}
+
+func TestDestination(t *testing.T) {
+	logs, dest := loadDestination()
+
+	dest.Debug("%s", "debug 100%")
+	dest.Info("%s", "info")
+	dest.Warning("%s", "warning")
+	dest.Error("%s", "error")
+	dest.Critical("%s", "critical")
+
+	expected := []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.ErrorLevel}
+	for i, entry := range logs.All() {
+		if entry.Level != expected[i] {
+			t.Error("Level not mapped correctly, expected", expected[i], "got", entry.Level)
+		}
+	}
+
+	if message := logs.All()[0].Message; message != "debug 100%" {
+		t.Error("Message not logged as is, got", message)
+	}
+}
+
+func TestDestination_formatted(t *testing.T) {
+	logs, dest := loadDestination()
+	formatter, _ := httpclerk.NewTextFormatter("foo")
+	logger, _ := httpclerk.NewHTTPLogger("foo", dest, formatter)
+	req, _ := http.NewRequest("PUT", "http://www.foo.com/1234.json", nil)
+
+	logger.Warning(httptest.NewRecorder(), req)
+
+	entry := logs.All()[0]
+	if entry.Level != zapcore.WarnLevel || len(entry.Context) != 0 {
+		t.Error("Formatted record not logged as the message, got", entry)
+	}
+}
+
+func TestDestination_structured(t *testing.T) {
+	logs, dest := loadDestination()
+	logger, _ := httpclerk.NewStructuredHTTPLogger("foo", dest)
+	req := httptest.NewRequest("GET", "http://www.foo.com/items?token=abc", nil)
+	req.Header.Set("User-Agent", "curl/7.30.0")
+
+	before := time.Now()
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpclerk.AddField(r.Context(), "tenant", "acme")
+		httpclerk.AddTiming(r.Context(), "db", 2*time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := logs.All()[0]
+	if entry.Level != zapcore.ErrorLevel || entry.Message != "GET /items?token=REDACTED 503" {
+		t.Error("Record not logged correctly, got", entry.Level, entry.Message)
+	}
+	if entry.Time.Before(before) || entry.Time.After(time.Now()) {
+		t.Error("Time not set to the request's start, got", entry.Time)
+	}
+
+	fields, ok := entry.ContextMap()["http"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Fields not logged under an http namespace, got", entry.ContextMap())
+	}
+
+	expected := map[string]interface{}{
+		"method":      "GET",
+		"status":      "503",
+		"path":        "/items?token=REDACTED",
+		"host":        "www.foo.com",
+		"remote_addr": "192.0.2.1:1234",
+		"tenant":      "acme",
+		"headers":     map[string]interface{}{"User-Agent": []interface{}{"curl/7.30.0"}},
+		"timings":     map[string]interface{}{"db": 2.0},
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(fields[key], value) {
+			t.Errorf("Field %s not logged correctly, expected %#v got %#v", key, value, fields[key])
+		}
+	}
+}
+
+func TestDestination_structuredLevelDisabled(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	dest, _ := NewDestination(zap.New(core))
+	logger, _ := httpclerk.NewStructuredHTTPLogger("foo", dest)
+	req, _ := http.NewRequest("GET", "http://www.foo.com/", nil)
+
+	logger.Info(httptest.NewRecorder(), req)
+	logger.Critical(httptest.NewRecorder(), req)
+
+	if logs.Len() != 1 || logs.All()[0].Level != zapcore.ErrorLevel {
+		t.Error("Only the Critical record expected, got", logs.All())
+	}
+}
@@ -0,0 +1,100 @@
+// Package zerologclerk logs httpclerk records to a zerolog Logger.
+package zerologclerk
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/zendesk/go-httpclerk"
+)
+
+// Destination logs records to a zerolog Logger.
+//
+// With httpclerk.NewHTTPLogger, it logs the formatted record as the message.
+// With httpclerk.NewStructuredHTTPLogger, the message is "METHOD PATH STATUS"
+// and the fields are logged in an "http" dictionary, with the time the
+// request started as zerolog.TimestampFieldName. Don't also add a timestamp
+// to the logger's context, or structured records get the field twice.
+//
+// Critical records are logged at Error, as zerolog's higher levels are for
+// panics and exits.
+type Destination struct {
+	logger zerolog.Logger
+}
+
+// NewDestination constructor.
+func NewDestination(logger zerolog.Logger) (*Destination, error) {
+	return &Destination{logger: logger}, nil
+}
+
+var levels = map[httpclerk.Level]zerolog.Level{
+	httpclerk.LevelDebug:    zerolog.DebugLevel,
+	httpclerk.LevelInfo:     zerolog.InfoLevel,
+	httpclerk.LevelWarning:  zerolog.WarnLevel,
+	httpclerk.LevelError:    zerolog.ErrorLevel,
+	httpclerk.LevelCritical: zerolog.ErrorLevel,
+}
+
+func (dest *Destination) Debug(data string, args ...interface{}) {
+	dest.log(dest.logger.Debug(), data, args)
+}
+
+func (dest *Destination) Info(data string, args ...interface{}) {
+	dest.log(dest.logger.Info(), data, args)
+}
+
+func (dest *Destination) Warning(data string, args ...interface{}) {
+	dest.log(dest.logger.Warn(), data, args)
+}
+
+func (dest *Destination) Error(data string, args ...interface{}) {
+	dest.log(dest.logger.Error(), data, args)
+}
+
+func (dest *Destination) Critical(data string, args ...interface{}) {
+	dest.log(dest.logger.Error(), data, args)
+}
+
+// Like Msgf, but without args the format is taken as is.
+func (dest *Destination) log(event *zerolog.Event, data string, args []interface{}) {
+	if len(args) == 0 {
+		event.Msg(data)
+		return
+	}
+	event.Msg(fmt.Sprintf(data, args...))
+}
+
+func (dest *Destination) LogRecord(record httpclerk.Record) {
+	event := dest.logger.WithLevel(levels[record.Level])
+	if event == nil {
+		return
+	}
+
+	event.Time(zerolog.TimestampFieldName, record.Time).Dict("http", dict(record.Attrs)).Msg(record.Message)
+}
+
+// Groups, like headers and timings, become nested dictionaries.
+func dict(attrs []httpclerk.Attr) *zerolog.Event {
+	d := zerolog.Dict()
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case []httpclerk.Attr:
+			d.Dict(attr.Key, dict(v))
+		case string:
+			d.Str(attr.Key, v)
+		case []string:
+			d.Strs(attr.Key, v)
+		case int64:
+			d.Int64(attr.Key, v)
+		case int:
+			d.Int(attr.Key, v)
+		case float64:
+			d.Float64(attr.Key, v)
+		case bool:
+			d.Bool(attr.Key, v)
+		default:
+			d.Interface(attr.Key, v)
+		}
+	}
+	return d
+}